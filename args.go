@@ -1,12 +1,19 @@
 package cli
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // Args represents the non-flag positional arguments of a command.
 type Args []string
 
-// Args returns the slice of Args
+// Args returns the slice of Args. For commands with flag parsing
+// disabled (see RawArgs) these are all tokens after the command path.
 func (c *Context) Args() Args {
+	if c.Cmd != nil && c.Cmd.DisableFlagParsing && len(c.RawArgs) > 0 {
+		return Args(c.RawArgs[1:])
+	}
 	return Args(c.Flags.Args())
 }
 
@@ -24,3 +31,37 @@ func (a Args) Get(i int) string {
 func (a Args) String() string {
 	return strings.Join(a, " ")
 }
+
+// RequireArgs returns the positional arguments, or a UsageError when
+// fewer than min were given.
+//
+//	files, err := c.RequireArgs(1)
+//	if err != nil {
+//		return err
+//	}
+func (c *Context) RequireArgs(min int) ([]string, error) {
+	args := c.Args()
+	if len(args) >= min {
+		return args, nil
+	}
+
+	name := c.App.Name
+	if c.Cmd != nil && c.Cmd.path != "" {
+		name += " " + c.Cmd.path
+	}
+	noun := "arguments"
+	if min == 1 {
+		noun = "argument"
+	}
+	return nil, &UsageError{Cmd: c.Cmd, Err: fmt.Errorf("%s requires at least %d %s, got %d", name, min, noun, len(args))}
+}
+
+// ArgOrDefault returns the i-th positional argument, or def when there
+// are not that many.
+func (c *Context) ArgOrDefault(i int, def string) string {
+	args := c.Args()
+	if i < 0 || i >= len(args) {
+		return def
+	}
+	return args[i]
+}
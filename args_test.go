@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRequireArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		min     int
+		wantErr string
+	}{
+		{nil, 0, ""},
+		{[]string{"a"}, 1, ""},
+		{[]string{"a", "b", "c"}, 2, ""},
+		{nil, 1, "app rm requires at least 1 argument, got 0"},
+		{[]string{"a"}, 2, "app rm requires at least 2 arguments, got 1"},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		var got []string
+		var err error
+		mustCommand(t, app, "rm", func(c *Context) error {
+			got, err = c.RequireArgs(tt.min)
+			return nil
+		})
+		if perr := app.Parse(append([]string{"rm"}, tt.args...)); perr != nil {
+			t.Fatal(perr)
+		}
+
+		if tt.wantErr == "" {
+			if err != nil || !slices.Equal(got, tt.args) {
+				t.Errorf("RequireArgs(%d) with %q = %q, %v", tt.min, tt.args, got, err)
+			}
+			continue
+		}
+		var ue *UsageError
+		if !errors.As(err, &ue) || ue.Err.Error() != tt.wantErr || got != nil {
+			t.Errorf("RequireArgs(%d) with %q = %q, %v, want UsageError %q", tt.min, tt.args, got, err, tt.wantErr)
+		}
+	}
+}
+
+func TestArgOrDefault(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got []string
+	mustCommand(t, app, "cp", func(c *Context) error {
+		got = []string{c.ArgOrDefault(0, "x"), c.ArgOrDefault(1, "y"), c.ArgOrDefault(-1, "z"), c.ArgOrDefault(2, "w")}
+		return nil
+	})
+	if err := app.Parse([]string{"cp", "a", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "", "z", "w"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxResponseDepth limits how deeply response files may include others.
+const maxResponseDepth = 10
+
+// expandResponseFiles replaces every "@path" argument with the
+// arguments read from that file, split on whitespace with quotes
+// handled like Tokenize. Backslashes are kept literal so Windows paths
+// such as C:\dir\file survive; quote an argument to keep its spaces.
+// Files may refer to further response files; a file that includes
+// itself, directly or not, is an error. Expansion stops at the first
+// "--" and after the path of a RawArgs command, so later arguments
+// such as "@v1.2" or "@user" are passed on as given.
+func (a *App) expandResponseFiles(args []string) ([]string, error) {
+	out, _, err := expandResponse(nil, args, nil, a.rawCommand)
+	return out, err
+}
+
+// expandResponse appends the expansion of args to out. Once literal
+// reports true for the arguments so far, or at a "--", the rest is
+// appended as is and stop is true.
+func expandResponse(out, args, chain []string, literal func([]string) bool) (_ []string, stop bool, _ error) {
+	for i, arg := range args {
+		if arg == "--" || literal(out) {
+			return append(out, args[i:]...), true, nil
+		}
+		path, ok := strings.CutPrefix(arg, "@")
+		if !ok || path == "" {
+			out = append(out, arg)
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("response file %s: %w", path, err)
+		}
+		for _, seen := range chain {
+			if seen == abs {
+				return nil, false, fmt.Errorf("response file %s includes itself", path)
+			}
+		}
+		if len(chain) >= maxResponseDepth {
+			return nil, false, fmt.Errorf("response file %s: nested too deeply", path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, fmt.Errorf("response file: %w", err)
+		}
+		tokens, err := tokenize(string(data), false)
+		if err != nil {
+			return nil, false, fmt.Errorf("response file %s: %w", path, err)
+		}
+		if out, stop, err = expandResponse(out, tokens, append(chain, abs), literal); err != nil {
+			return nil, false, err
+		}
+		if stop {
+			return append(out, args[i+1:]...), true, nil
+		}
+	}
+	return out, false, nil
+}
+
+// rawCommand reports whether args, global flags first, name a command
+// with RawArgs, whose arguments are taken as given.
+func (a *App) rawCommand(args []string) bool {
+	_, tail := a.splitGlobals(args)
+	if len(tail) == 0 {
+		return false
+	}
+	n, _, err := a.root.resolve(tail, a.config.abbrev, a.config.fold)
+	return err == nil && n != a.root && n.cmd != nil && n.cmd.DisableFlagParsing
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := writeFile(t, dir, "inner.txt", "--region eu\n")
+	outer := writeFile(t, dir, "outer.txt", `--name "John Doe" @`+inner+` C:\Users\john\file.txt 'a b'`)
+
+	app, _, _ := newTestApp(t, FluxResponseFiles(true))
+	var got []string
+	mustCommand(t, app, "greet", func(c *Context) error {
+		got = append([]string{c.GetString("name"), c.GetString("region")}, c.Args()...)
+		return nil
+	}, Flags(String("name"), String("region")))
+
+	if err := app.Parse([]string{"greet", "@" + outer}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"John Doe", "eu", `C:\Users\john\file.txt`, "a b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResponseFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	self := filepath.Join(dir, "self.txt")
+	writeFile(t, dir, "self.txt", "@"+self)
+
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"@" + filepath.Join(dir, "missing.txt"), "response file"},
+		{"@" + self, "includes itself"},
+		{"@" + writeFile(t, dir, "open.txt", `"unterminated`), "unterminated"},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t, FluxResponseFiles(true))
+		var handled error
+		app.OnError = func(_ *Context, err error) error { handled = err; return err }
+		mustCommand(t, app, "greet", func(*Context) error { return nil })
+
+		if err := app.Execute([]string{"greet", tt.arg}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Execute(%q) error = %v, want %q", tt.arg, err, tt.want)
+		}
+		if handled == nil {
+			t.Errorf("Execute(%q): OnError not called", tt.arg)
+		}
+	}
+}
+
+func TestResponseFilesLiteral(t *testing.T) {
+	dir := t.TempDir()
+	names := writeFile(t, dir, "names.txt", "--name ann")
+	cmd := writeFile(t, dir, "cmd.txt", "git log")
+	dashes := writeFile(t, dir, "dashes.txt", "bob -- @"+names)
+
+	app, _, _ := newTestApp(t, FluxResponseFiles(true))
+	var got []string
+	mustCommand(t, app, "greet", func(c *Context) error {
+		got = append([]string{c.GetString("name")}, c.Args()...)
+		return nil
+	}, Flags(String("name")))
+	mustCommand(t, app, "git", func(c *Context) error {
+		got = c.Args()
+		return nil
+	}, RawArgs())
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"greet", "@" + names, "--", "@" + names, "@v1.2"}, []string{"ann", "@" + names, "@v1.2"}},
+		{[]string{"greet", "@" + dashes, "@" + names}, []string{"", "bob", "--", "@" + names, "@" + names}},
+		{[]string{"git", "show", "@" + names}, []string{"show", "@" + names}},
+		{[]string{"@" + cmd, "@{upstream}"}, []string{"log", "@{upstream}"}},
+	}
+	for _, tt := range tests {
+		got = nil
+		if err := app.Parse(tt.args); err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -1,40 +1,143 @@
 package cli
 
-import "fmt"
+import (
+	"flag"
+	"fmt"
+	"io"
+)
 
-// default help using app.Out as its output
-func (a *App) PrintRootHelp() error {
-	if a.Version != "" {
-		fmt.Fprintf(a.Out, "%s - v%s\n", a.Name, a.Version)
-	} else {
-		fmt.Fprintf(a.Out, "%s\n", a.Name)
-	}
+// BuiltinPlugin installs the default "version" and "help" commands and
+// the --help flag, plus a --version flag if asked for. Registering a
+// command with either name replaces the builtin one.
+//
+// New adopts BuiltinPlugin{} unless FluxNoBuiltins is given. To choose
+// the builtins, turn the default off and adopt your own:
+//
+//	app := cli.New("app", cli.FluxNoBuiltins())
+//	app.Adopt(cli.Builtins(cli.WithVersionCmd(false), cli.WithVersionFlag(true)))
+type BuiltinPlugin struct {
+	noVersionCmd bool
+	versionFlag  bool
+	noHelpCmd    bool
+	noHelpFlag   bool
+}
 
-	if a.Desc != "" {
-		fmt.Fprintf(a.Out, "\n%s\n", a.Desc)
+// BuiltinOption configures a BuiltinPlugin, see Builtins.
+type BuiltinOption func(*BuiltinPlugin)
+
+// Builtins returns a BuiltinPlugin with the given options applied.
+func Builtins(opts ...BuiltinOption) BuiltinPlugin {
+	var p BuiltinPlugin
+	for _, o := range opts {
+		o(&p)
 	}
+	return p
+}
 
-	return nil
+// WithVersionCmd toggles the "version" command (default on).
+func WithVersionCmd(on bool) BuiltinOption {
+	return func(p *BuiltinPlugin) { p.noVersionCmd = !on }
+}
+
+// WithVersionFlag toggles a global --version flag (default off).
+func WithVersionFlag(on bool) BuiltinOption {
+	return func(p *BuiltinPlugin) { p.versionFlag = on }
 }
 
-type BuiltinPlugin struct{}
+// WithHelpCmd toggles the "help" command (default on).
+func WithHelpCmd(on bool) BuiltinOption {
+	return func(p *BuiltinPlugin) { p.noHelpCmd = !on }
+}
+
+// WithHelpFlag toggles the global --help/-h flag (default on).
+func WithHelpFlag(on bool) BuiltinOption {
+	return func(p *BuiltinPlugin) { p.noHelpFlag = !on }
+}
 
 func (p BuiltinPlugin) Sparkle(a *App) error {
 	// Honour user-supplied "version" command.
-	if _, ok := a.root.child["version"]; ok {
-		return nil
+	if _, ok := a.root.child["version"]; !ok && !p.noVersionCmd {
+		a.Command("version", func(c *Context) error {
+			if c.GetBool("plugins") {
+				for _, p := range c.App.Plugins() {
+					fmt.Fprintln(c.Out(), pluginName(p))
+				}
+				return nil
+			}
+			if c.GetBool("json") {
+				if c.App.Version == "" {
+					return fmt.Errorf("version not set")
+				}
+				return c.App.VersionJSON(c.Out())
+			}
+			return c.App.printVersion(c.Out())
+		}, Short("print the app version."),
+			Flags(Bool("json").Help("print as JSON."),
+				Bool("plugins").Help("list installed plugins.")))
 	}
 
-	a.Command("version", func(c *Context) error {
-		if c.App.Version == "" {
-			return fmt.Errorf("version not set")
-		}
-		_, err := fmt.Fprintln(c.App.Out, c.App.Version)
-		return err
-	})
+	// Same for "help".
+	if _, ok := a.root.child["help"]; !ok && !p.noHelpCmd {
+		a.Command("help", helpCommand,
+			Short("show help for the app or a command."),
+			Usage("help [command]"),
+			Flags(Bool("json").Help("print as JSON.")))
+	}
 
 	// builtin help flag
-	a.Flags(Bool("help", "h").Help("show help."))
+	if !p.noHelpFlag {
+		a.Flags(Bool("help", "h").Help("show help."))
+	}
+
+	// builtin version flag
+	if p.versionFlag {
+		a.versionFlag = Bool("version").Help("print the app version.")
+		a.Flags(a.versionFlag)
+	}
 
 	return nil
 }
+
+// printVersion writes App.Version to w.
+func (a *App) printVersion(w io.Writer) error {
+	if a.Version == "" {
+		return fmt.Errorf("version not set")
+	}
+	_, err := fmt.Fprintln(w, a.Version)
+	return err
+}
+
+// versionRequested reports whether the builtin --version flag is among
+// the global flag tokens in pre, see splitGlobals.
+func (a *App) versionRequested(pre []string) bool {
+	if a.versionFlag == nil {
+		return false
+	}
+	for _, tok := range pre {
+		switch tok {
+		case "--version", "-version", "--version=true", "-version=true":
+			return true
+		}
+	}
+	return false
+}
+
+// rootHelpJSON reports whether args ask for the app's help as JSON,
+// e.g. "app --help --json", with no command given. The globals are
+// parsed into copies so the declared flags stay untouched.
+func (a *App) rootHelpJSON(args []string) bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for _, g := range a.globals {
+		cloneFlag(g).apply(fs)
+	}
+	if fs.Lookup("help") == nil || fs.Lookup("json") != nil {
+		return false
+	}
+	asJSON := fs.Bool("json", false, "")
+
+	if fs.Parse(args) != nil || fs.NArg() > 0 {
+		return false
+	}
+	return *asJSON && fs.Lookup("help").Value.String() == "true"
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNoBuiltins(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxNoBuiltins(), SetVersion("1.0"))
+	var nf *ErrCommandNotFound
+	for _, path := range []string{"version", "help"} {
+		if err := app.Parse([]string{path}); !errors.As(err, &nf) || nf.Name != path {
+			t.Errorf("Parse(%q) = %v, want not found", path, err)
+		}
+	}
+	if err := app.Parse([]string{"--help"}); err == nil {
+		t.Error("Parse(--help) succeeded without a help flag")
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxNoBuiltins(), SetVersion("1.0"))
+	app.Adopt(Builtins(WithVersionCmd(false), WithVersionFlag(true)))
+
+	if err := app.Parse([]string{"--version"}); err != nil || out.String() != "1.0\n" {
+		t.Errorf("--version printed %q, %v", out, err)
+	}
+	var nf *ErrCommandNotFound
+	if err := app.Parse([]string{"version"}); !errors.As(err, &nf) {
+		t.Errorf("Parse(version) = %v, want not found", err)
+	}
+	if _, ok := app.Lookup("help"); !ok {
+		t.Error("help command missing")
+	}
+}
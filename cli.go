@@ -2,17 +2,31 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // internal sentinel for root override
 const rootCommandPath = ""
 
+// default limit for nested Context.Exec calls
+const defaultMaxDepth = 32
+
 // App represents your command line application.
 // Create on using New() and configure it with "settings", commands, and flags
 //
@@ -24,11 +38,19 @@ type App struct {
 	Desc    string
 
 	// behaviour hooks
-	OnNotFound NotFoundHandler
-	OnError    ErrorHandler
+	OnNotFound        NotFoundHandler
+	OnError           ErrorHandler
+	OnCommandComplete CompleteHandler
+
+	// OnFlagError, when set, gets the UsageError of a command line
+	// that failed flag parsing, before OnError. What it returns
+	// replaces the error; nil drops it and runs the command with the
+	// flags parsed so far.
+	OnFlagError ErrorHandler
 
 	// I/O streams used by the framework and user handlers.
-	// Default are os.Stdout and os.Stderr respectively.
+	// Default are os.Stdin, os.Stdout and os.Stderr respectively.
+	In  io.Reader // interactive input, see Context.Prompt
 	Out io.Writer // normal command output
 	Err io.Writer // error messages output
 
@@ -39,18 +61,65 @@ type App struct {
 	plugins        []Plugin             // Registered plugins.
 	globals        []Flag               // global flags
 	helpFlagAction func(*Context) error // help flag handler
+
+	fallback    func(*Context, string, []string) error // see SetFallback
+	transforms  []ArgsTransformer                      // see BeforeParse
+	topics      map[string]helpTopic                   // see AddHelpTopic
+	catOrder    []string                               // see SetCategoryOrder
+	versionFlag *boolFlag                              // builtin --version, see WithVersionFlag
+	verboseFlag *countFlag                             // see FluxVerbosity
+	verbosity   atomic.Int32                           // -v count of the latest run
+
+	in    *bufio.Reader // buffered App.In, see readLine
+	inSrc io.Reader     // reader in was built from
+
+	pending chan lineRead // read left running by an interrupted prompt
+
+	store Store // shared data, see Context.Store
+
+	beforeCmd []func(*Context, *Command) error  // see BeforeCommand
+	afterCmd  []func(*Context, *Command, error) // see AfterCommand
+
+	resultMu sync.Mutex
+	result   any // last value passed to Context.SetResult
+
+	outMu sync.Mutex // guards wrapping App.Out, see runOut
+
+	shutdownOnce sync.Once // see Shutdown
+
+	statsMu sync.Mutex
+	stats   map[string]int // runs per command path, see RunStats
 }
 
 // appConfig holds non-exported settings modified through ConfigOption.
 type appConfig struct {
 	debug        bool
 	log          *log.Logger
+	logger       Logger       // overrides log when set
+	slog         *slog.Logger // overrides logger when set
 	trace        bool
 	panicHandler func(any)
+	panicStack   func(any, []byte) // overrides panicHandler when set
+	maxDepth     int               // maximum Context.Exec nesting
+	sources      []Source          // flag value precedence, see FlagSources
+	values       map[string]string // SourceConfig values by flag name
+	replPrompt   string            // see RunREPL
+	noBuiltins   bool              // skip BuiltinPlugin, see FluxNoBuiltins
+	needAction   bool              // see FluxMustHaveAction
+	abbrev       bool              // accept command prefixes, see FluxAbbreviations
+	flagAbbrev   bool              // accept long flag prefixes, see FluxFlagAbbreviations
+	flagErrors   FlagErrorMode     // see FluxFlagErrorHandling
+	helpWidth    int               // help line width, 0 to detect
+	respFiles    bool              // expand @file arguments, see FluxResponseFiles
+	interactive  bool              // prompt for missing required flags
+	fold         bool              // case-insensitive names, see FluxCaseInsensitive
+	noDeprecated bool              // see FluxDeprecatedAsErrors
+	outFilter    func([]byte) []byte
 }
 
 // Command represents a runnable sub-command. Name and Aliases are Only
-// Advisory; the actual registration path is determined by App.Command().
+// Advisory; the actual registration path is determined by App.Command(),
+// or by Name for App.AddCommands.
 type Command struct {
 	Name     string
 	Aliases  []string
@@ -58,28 +127,90 @@ type Command struct {
 	Short    string
 	Long     string
 	Category string
+	Examples []string // free-form, e.g. a command line with a comment
+	Hidden   bool     // left out of help listings, still runnable
 
 	Before func(*Context) error // Executed before Action.
 	Action func(*Context) error // Required logic; must be non-nil.
 	After  func(*Context) error // Executed after Action even if it errors.
 
+	// OnSuccess runs last, only when Before, Action and After all
+	// returned nil: Before -> Action -> After -> OnSuccess.
+	OnSuccess func(*Context) error
+
+	// Flags declares the command's flags. Runs parse copies of them,
+	// so read values through Context, never from here.
 	Flags *flag.FlagSet
+
+	// DisableFlagParsing passes every token after the command path
+	// to Action untouched, including ones that look like flags.
+	DisableFlagParsing bool
+
+	// Timeout bounds the run time of Action, see the Timeout option.
+	Timeout time.Duration
+
+	path     string       // full registration path, set by App.add
+	flags    []Flag       // typed flags in declaration order, see FlagInfos
+	override bool         // replace an existing command, see Override
+	retry    *retryPolicy // re-run a failing Action, see Retry
+	order    int          // help listing weight, see Order
+	unknown  bool         // pass unknown flags on as args, see AllowUnknownFlags
+
+	persistent []Flag // also offered to subcommands, see PersistentFlags
+
+	notFound NotFoundHandler // unknown subcommands below, see NotFound
+
+	deprecated *ErrDeprecatedCommand // see Deprecated
+}
+
+// deprecation returns the deprecation details of c, marking it
+// deprecated.
+func (c *Command) deprecation() *ErrDeprecatedCommand {
+	if c.deprecated == nil {
+		c.deprecated = &ErrDeprecatedCommand{}
+	}
+	return c.deprecated
 }
 
 // Plugin is the extension point for reusable behaviour such as
-// middleware, extra commands or global flag injection
+// middleware, extra commands or global flag injection.
+// Optional Name() and Version() string methods are used when listing
+// plugins with "version --plugins".
 type Plugin interface {
 	// Install is called once when the plugin is registered via App.Use.
 	Sparkle(*App) error
 }
 
-// NotFoundHandler is invoked when no matching command is found.
+// ShutdownPlugin is a Plugin that releases resources once the app is
+// done. Run and RunREPL call Shutdown on every installed plugin that has
+// it when they finish, in reverse install order, whether or not the
+// command failed; see App.Shutdown.
+type ShutdownPlugin interface {
+	Plugin
+	Shutdown(*App) error
+}
+
+// NotFoundHandler is invoked when no matching command is found, with
+// the first unmatched token. The Context's Cmd is the deepest command
+// that did match (nil at the top level) and RawArgs holds the
+// unmatched tokens. The default handler returns an ExitError with code
+// 127; return nil to treat unknown commands as success. See also the
+// NotFound command option.
 type NotFoundHandler func(*Context, string) error
 
 // ErrorHandler is invoked whenever Command.Action, Before, or After
 // returns a non-nil error.
 type ErrorHandler func(*Context, error) error
 
+// CompleteHandler is invoked after every command execution with the
+// command path, the time spent in Before, Action and After combined,
+// and the resulting error (nil on success).
+type CompleteHandler func(path string, d time.Duration, err error)
+
+// ArgsTransformer rewrites the command line before it is parsed, see
+// App.BeforeParse.
+type ArgsTransformer func(args []string) ([]string, error)
+
 // node is the internal command tree nkde.
 type node struct {
 	cmd   *Command
@@ -98,6 +229,58 @@ func (n *node) get(parts []string) (*node, []string) {
 	return cur, nil
 }
 
+// resolve is get for command lines: with abbrev, a word that names no
+// child selects the only visible child it is a prefix of. Several
+// candidates make it an error. With fold, words match names in any
+// case; an exact match still wins.
+func (n *node) resolve(parts []string, abbrev, fold bool) (*node, []string, error) {
+	if !abbrev && !fold {
+		cur, rest := n.get(parts)
+		return cur, rest, nil
+	}
+
+	hasPrefix := strings.HasPrefix
+	if fold {
+		hasPrefix = func(s, prefix string) bool {
+			return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+		}
+	}
+
+	cur := n
+	for i, p := range parts {
+		next, ok := cur.child[p]
+		if !ok && fold {
+			for _, name := range sortedKeys(cur.child) {
+				if strings.EqualFold(name, p) {
+					next, ok = cur.child[name], true
+					break
+				}
+			}
+		}
+		if !ok && abbrev && p != "" && !strings.HasPrefix(p, "-") {
+			var matches []string
+			for _, name := range sortedKeys(cur.child) {
+				c := cur.child[name]
+				if hasPrefix(name, p) && (c.cmd == nil || !c.cmd.Hidden) {
+					matches = append(matches, name)
+				}
+			}
+			switch len(matches) {
+			case 0:
+			case 1:
+				next, ok = cur.child[matches[0]], true
+			default:
+				return cur, parts[i:], fmt.Errorf("ambiguous command %q: could be %s", p, strings.Join(matches, ", "))
+			}
+		}
+		if !ok {
+			return cur, parts[i:], nil
+		}
+		cur = next
+	}
+	return cur, nil, nil
+}
+
 // --- internal helper ---
 func isBuiltin(name string) bool {
 	switch name {
@@ -107,13 +290,6 @@ func isBuiltin(name string) bool {
 	return false
 }
 
-func (a *App) debugf(format string, v ...any) {
-	if !a.config.debug && !a.config.trace {
-		return
-	}
-	a.config.log.Printf("[%s] %s", a.Name, fmt.Sprintf(format, v...))
-}
-
 // add inserts cmd into the tree at the given path.
 func (a *App) add(path string, cmd *Command) (*App, error) {
 	// root override
@@ -123,22 +299,55 @@ func (a *App) add(path string, cmd *Command) (*App, error) {
 	}
 
 	parts := strings.Split(path, " ")
-	cur, _ := a.root.get(parts[:len(parts)-1])
+	cur, missing := a.root.get(parts[:len(parts)-1])
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("command %q: parent %q is not registered", path, strings.Join(parts[:len(parts)-1], " "))
+	}
 	name := parts[len(parts)-1]
 
-	if _, ok := cur.child[name]; ok {
-		if isBuiltin(name) {
-			delete(cur.child, name)
-		} else {
-			return nil, fmt.Errorf("duplicate command: %s", path)
+	cmd.Name = name
+	cmd.path = path
+	if cmd.deprecated != nil {
+		cmd.deprecated.Path = path
+	}
+
+	if n, ok := cur.child[name]; ok {
+		if !isBuiltin(name) && !cmd.override {
+			return nil, &ErrDuplicateCommand{Path: path}
 		}
+		// replace in place, subcommands stay
+		n.cmd = cmd
+		return a, nil
 	}
 
-	cmd.Name = name
 	cur.child[name] = &node{cmd: cmd, child: make(map[string]*node)}
 	return a, nil
 }
 
+// Remove unregisters the command at path together with its
+// subcommands and reports whether anything was removed. The empty
+// path clears the root command.
+func (a *App) Remove(path string) bool {
+	if path == rootCommandPath {
+		removed := a.root.cmd != nil
+		a.root.cmd = nil
+		return removed
+	}
+
+	parts := strings.Split(path, " ")
+	parent, ok := a.lookupNode(strings.Join(parts[:len(parts)-1], " "))
+	if !ok {
+		return false
+	}
+
+	name := parts[len(parts)-1]
+	if _, ok := parent.child[name]; !ok {
+		return false
+	}
+	delete(parent.child, name)
+	return true
+}
+
 // New creates a fresh CLI application ready for configuration.
 // The name should match your executable name (e.g. "git" or "docker").
 // Can be configure with settings:
@@ -152,27 +361,43 @@ func New(name string, opts ...ConfigOption) *App {
 	app := &App{
 		Name: name,
 		OnNotFound: func(ctx *Context, s string) error {
-			fmt.Fprintf(ctx.App.Err, "command %s not found\n", s)
-			return nil
+			if ctx.Cmd != nil && ctx.Cmd.path != "" {
+				return &ExitError{Code: 127, Err: &ErrCommandNotFound{Name: s, Parent: ctx.Cmd.path}}
+			}
+			return &ExitError{Code: 127, Err: &ErrCommandNotFound{Name: s}}
 		},
 		OnError: func(ctx *Context, err error) error {
-			fmt.Fprintln(ctx.App.Err, err)
+			if !ctx.App.reportError(err) {
+				fmt.Fprintln(ctx.App.Err, err)
+			}
+
+			var ue *UsageError
+			if errors.As(err, &ue) && ue.Cmd != nil {
+				fmt.Fprintln(ctx.App.Err)
+				ctx.App.writeHelp(ctx.App.Err, ue.Cmd)
+			}
 			return err
 		},
+		In:   os.Stdin,
 		Out:  os.Stdout,
 		Err:  os.Stderr,
 		root: &node{child: make(map[string]*node)},
 		config: appConfig{
-			debug: false,
-			log:   log.New(os.Stderr, "[DEBUG] ", log.Ltime),
+			debug:    false,
+			log:      log.New(os.Stderr, "[DEBUG] ", log.Ltime),
+			maxDepth: defaultMaxDepth,
 		},
 	}
 
+	app.store = NewMapStore()
+
 	for _, o := range opts {
 		o(app)
 	}
 
-	app.Adopt(BuiltinPlugin{})
+	if !app.config.noBuiltins {
+		app.Adopt(BuiltinPlugin{})
+	}
 
 	return app
 }
@@ -184,6 +409,8 @@ func New(name string, opts ...ConfigOption) *App {
 //	app.Command("server start", ...)  // Creates nested "server start" command
 //	app.Command("status", ...)        // Creates top-level "status" command
 //
+// A nested command's parent must be registered first.
+//
 // You can provide configuration options:
 //
 //	app.Command("hello", func(c *cli.Context) error { ... })
@@ -197,28 +424,118 @@ func (a *App) Command(path string, fn func(*Context) error, opts ...CommandOptio
 		o(cmd)
 	}
 
+	return a.register(path, cmd)
+}
+
+// AddCommands registers ready-made commands, each at the path in its
+// Name, such as "db migrate". Parents are added before their children
+// whatever the order given. Unlike Command it does not stop at the
+// first failure: every command that can be added is, and the errors of
+// the others are joined.
+//
+//	err := app.AddCommands(
+//		&cli.Command{Name: "db", Short: "database tools"},
+//		&cli.Command{Name: "db migrate", Action: migrate},
+//	)
+func (a *App) AddCommands(cmds ...*Command) error {
+	depth := func(c *Command) int {
+		if c == nil {
+			return 0
+		}
+		return strings.Count(c.Name, " ")
+	}
+	cmds = slices.Clone(cmds)
+	slices.SortStableFunc(cmds, func(x, y *Command) int { return depth(x) - depth(y) })
+
+	var errs []error
+	for _, cmd := range cmds {
+		switch {
+		case cmd == nil:
+			errs = append(errs, errors.New("nil command"))
+		case strings.TrimSpace(cmd.Name) == "":
+			errs = append(errs, errors.New("command without a name, use App.Root for the root command"))
+		default:
+			if _, err := a.register(cmd.Name, cmd); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// register checks cmd's flags against each other and against the
+// globals and ancestor persistent flags it inherits, then adds it at
+// path.
+func (a *App) register(path string, cmd *Command) (*App, error) {
+	shared := a.globals
+	if path != rootCommandPath {
+		cmd.path = path
+		shared = a.inheritedFlags(cmd)
+	}
+	if err := checkFlagNames(cmd.withGlobals(shared)); err != nil {
+		return nil, fmt.Errorf("command %q: %w", path, err)
+	}
+	if cmd.Flags != nil {
+		cmd.Flags.SetOutput(a.Err)
+	}
+
 	return a.add(path, cmd)
 }
 
+// Root sets the command run when no subcommand is given, such as a
+// bare "app" or "app --port 80". Its flags are parsed like any other
+// command's, and they are the only flags a bare invocation accepts
+// besides the globals. Calling Root again replaces the command.
+//
+//	app.Root(serve, cli.Flags(cli.Int("port").Default(8080)))
+func (a *App) Root(fn func(*Context) error, opts ...CommandOption) (*App, error) {
+	return a.Command(rootCommandPath, fn, opts...)
+}
+
+// RootExists reports whether a root command is set, see Root.
+func (a *App) RootExists() bool {
+	return a.root.cmd != nil
+}
+
+// newFlagSet returns a FlagSet that writes to App.Err.
+func (a *App) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Err)
+	return fs
+}
+
 // Adopt registers zero or more plugins
 //
 //	app.Adopt(&plugin1{}, &plugin2{}, ...)
 func (a *App) Adopt(p ...Plugin) *App {
 	for i, pl := range p {
 		if pl == nil {
-			a.debugf("plugin at index %d is nil", i)
+			a.debug("plugin is nil", "index", i)
 			continue
 		}
 
-		if err := pl.Sparkle(a); err != nil {
+		if err := a.install(pl); err != nil {
 			panic(err)
 		}
 	}
 	return a
 }
 
+// install runs the plugin's Sparkle and records it on success.
+func (a *App) install(pl Plugin) error {
+	if err := pl.Sparkle(a); err != nil {
+		return err
+	}
+	a.plugins = append(a.plugins, pl)
+	return nil
+}
+
 // --- execution helpers ---
-func (a *App) execute(c *Command, args []string) (err error) {
+
+// execute runs c with args, its name followed by what came after its
+// path. pre are the global flags given before the path, see
+// splitGlobals; they are parsed even for raw commands.
+func (a *App) execute(parent *Context, c *Command, pre, args []string) (err error) {
 	if c == nil {
 		if len(args) == 0 && a.root.cmd != nil {
 			c = a.root.cmd
@@ -226,57 +543,41 @@ func (a *App) execute(c *Command, args []string) (err error) {
 		return fmt.Errorf("no command defined: status Nil Command")
 	}
 
-	if c.Flags == nil {
-		c.Flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
-	}
+	a.countRun(c.path)
 
 	if c == a.root.cmd {
 		args = append([]string{""}, args...)
 	}
 
-	fs := c.Flags
-
-	for _, gf := range a.globals {
-		gf.apply(fs)
+	shared := a.inheritedFlags(c)
+	if err := checkFlagNames(c.withGlobals(shared)); err != nil {
+		return fmt.Errorf("command %q: %w", c.path, err)
 	}
+	fs, copies := a.bindFlags(c, shared)
 
-	if err := fs.Parse(args[1:]); err != nil {
-		return err
+	ff := c.withGlobals(shared)
+	for i, f := range ff {
+		ff[i] = copies[f]
 	}
-
-	if err := fs.Parse(args[1:]); err != nil {
+	// raw commands get every token after their path verbatim, see
+	// RawArgs, so only the globals before it are parsed
+	flagArgs := append(pre[:len(pre):len(pre)], args[1:]...)
+	if c.DisableFlagParsing {
+		flagArgs = pre
+	}
+	if done, err := a.parseFlags(parent, c, fs, ff, copies, flagArgs); done || err != nil {
 		return err
 	}
 
-	h := fs.Lookup("help")
-	if h != nil && h.Value.String() == "true" {
-		ctx := &Context{App: a, Cmd: c, Flags: fs}
-		if a.helpFlagAction != nil {
-			return a.helpFlagAction(ctx)
-		}
-		return a.PrintRootHelp()
+	if c.Action == nil {
+		return &ErrNoAction{Path: c.path}
 	}
 
-	// validate required flags & ranges
-	c.Flags.VisitAll(func(f *flag.Flag) {
-		req, ok := f.Value.(interface{ Required() bool })
-		if ok && req.Required() {
-			if !isFlagPassed(c.Flags, f.Name) {
-				err = fmt.Errorf("required flag --%s not provided", f.Name)
-			}
+	if d := c.deprecated; d != nil {
+		if a.config.noDeprecated {
+			return d
 		}
-
-		v, ok := f.Value.(interface{ Validate() error })
-		if ok {
-			e := v.Validate()
-			if e != nil {
-				err = e
-			}
-		}
-	})
-
-	if c.Action == nil {
-		return fmt.Errorf("no action defined for: %s", c.Name)
+		a.warn("%v", d)
 	}
 
 	ctx := &Context{
@@ -284,88 +585,580 @@ func (a *App) execute(c *Command, args []string) (err error) {
 		Cmd:     c,
 		RawArgs: args,
 		Flags:   fs,
+		Ctx:     parent.baseCtx(),
+		depth:   parent.nextDepth(),
+		copies:  copies,
+		out:     a.runOut(),
+	}
+
+	// AfterCommand hooks see the final error, a panic's included
+	if len(a.afterCmd) > 0 {
+		defer func() {
+			if r := recover(); r != nil {
+				err = a.panicError(r, debug.Stack())
+			}
+			for _, fn := range a.afterCmd {
+				fn(ctx, c, err)
+			}
+		}()
+	}
+	for _, fn := range a.beforeCmd {
+		if err = fn(ctx, c); err != nil {
+			return err
+		}
 	}
 
 	if c.Before != nil {
+		a.logf(2, "running Before of %q", c.path)
 		if err = c.Before(ctx); err != nil {
 			return err
 		}
 	}
 
+	returned := false // false while a panic unwinds through here
 	defer func() {
 		if c.After != nil {
+			a.logf(2, "running After of %q", c.path)
 			if e := c.After(ctx); e != nil && err == nil {
 				err = e
 			}
 		}
+		if c.OnSuccess != nil && returned && err == nil {
+			a.logf(2, "running OnSuccess of %q", c.path)
+			err = c.OnSuccess(ctx)
+		}
 	}()
 
+	a.logf(2, "running %q with args %q", c.path, fs.Args())
+	if ctx.DryRun() {
+		a.debug("[dry-run] running", "command", c.path)
+	}
+	if c.retry != nil {
+		err = a.runWithRetry(ctx, c)
+	} else {
+		err = a.runAction(ctx, c)
+	}
+	returned = true
+	return err
+}
+
+// runAction runs c.Action once, under c.Timeout if set.
+func (a *App) runAction(ctx *Context, c *Command) error {
+	if c.Timeout > 0 {
+		return a.runWithTimeout(ctx, c)
+	}
 	return c.Action(ctx)
 }
 
+// runWithTimeout runs c.Action under a deadline of c.Timeout. The
+// Action is not interrupted; it has to watch ctx.Ctx.Done() and return
+// early. After is run with the original, deadline-free Ctx.
+func (a *App) runWithTimeout(ctx *Context, c *Command) error {
+	base := ctx.Ctx
+	tctx, cancel := context.WithTimeout(base, c.Timeout)
+	defer func() {
+		cancel()
+		ctx.Ctx = base
+	}()
+
+	ctx.Ctx = tctx
+	err := c.Action(ctx)
+	if errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("command %q timed out after %s: %w", c.path, c.Timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// parseFlags parses args into fs, the FlagSet of this run from
+// bindFlags, and validates the result. ff are the flags of the run,
+// globals included, and copies maps declared flags to them. done
+// reports that the help flag was handled and the command must not run.
+func (a *App) parseFlags(parent *Context, c *Command, fs *flag.FlagSet, ff []Flag, copies map[Flag]Flag, args []string) (done bool, err error) {
+	// the flag package writes its own error and usage dump on parse
+	// errors; by default OnError reports them instead
+	if a.config.flagErrors != FlagErrorsStdlib {
+		fs.SetOutput(io.Discard)
+		defer fs.SetOutput(a.Err)
+	}
+
+	if c.unknown {
+		args = splitUnknown(fs, args, a.config.fold)
+	}
+	if a.config.fold {
+		args = foldFlags(fs, args)
+	}
+	args = expandCounts(fs, args)
+	if a.config.flagAbbrev {
+		if args, err = expandFlags(fs, args); err != nil {
+			return false, &UsageError{Cmd: c, Err: err}
+		}
+	}
+
+	ctx := &Context{App: a, Cmd: c, Flags: fs, Ctx: parent.baseCtx(), depth: parent.nextDepth(), copies: copies, out: a.runOut()}
+
+	if err := fs.Parse(args); err != nil {
+		err = &UsageError{Cmd: c, Err: suggestFlag(fs, err)}
+		if a.OnFlagError == nil {
+			return false, err
+		}
+		if err := a.OnFlagError(ctx, err); err != nil {
+			return false, err
+		}
+	}
+
+	// globals passed on by ExecInherit, unless given again here or
+	// shadowed by a local flag
+	if parent != nil {
+		for name, vals := range parent.inherit {
+			f := fs.Lookup(name)
+			g, _ := copies[findFlag(a.globals, name)].(flag.Value)
+			if f == nil || g == nil || f.Value != g || isFlagPassed(fs, name) {
+				continue
+			}
+			for _, v := range vals {
+				if err := f.Value.Set(v); err != nil {
+					return false, &UsageError{Cmd: c, Err: fmt.Errorf("inherited flag --%s: %w", name, err)}
+				}
+			}
+		}
+	}
+
+	// help and version win over everything below, so a missing
+	// required flag or a bad env value never hides them
+	h := fs.Lookup("help")
+	if h != nil && h.Value.String() == "true" {
+		if a.helpFlagAction != nil {
+			return true, a.helpFlagAction(ctx)
+		}
+		// --help --json, for commands that have a json flag
+		if ctx.GetBool("json") {
+			if c == a.root.cmd {
+				return true, a.HelpJSON(ctx.Out())
+			}
+			return true, a.commandHelpJSON(ctx.Out(), c)
+		}
+		return true, a.writeHelp(ctx.Out(), c)
+	}
+
+	if vf, ok := copies[a.versionFlag].(*boolFlag); ok && vf.val {
+		if v := fs.Lookup("version"); v != nil && v.Value == flag.Value(vf) {
+			return true, a.printVersion(ctx.Out())
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if r, ok := f.Value.(renamedFlag); ok {
+			a.warn("flag --%s is deprecated, use --%s instead", r.old, r.name)
+		}
+	})
+
+	if err := a.resolveSources(fs, ff); err != nil {
+		return false, &UsageError{Cmd: c, Err: err}
+	}
+	if vf, ok := copies[a.verboseFlag].(*countFlag); ok {
+		a.verbosity.Store(int32(vf.val))
+	}
+
+	if err := resolveDefaults(ctx, ff); err != nil {
+		return false, &UsageError{Cmd: c, Err: err}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		a.trace("parsed flag", "command", c.Name, "flag", f.Name, "value", f.Value.String())
+	})
+
+	if a.config.interactive && a.interactiveIn() {
+		if err := ctx.promptRequired(); err != nil {
+			return false, err
+		}
+	}
+
+	if err := validateFlags(fs); err != nil {
+		return false, &UsageError{Cmd: c, Err: err}
+	}
+	return false, nil
+}
+
 // internal recover wrapper
-func (a *App) safeExecute(c *Command, args []string) (err error) {
+func (a *App) safeExecute(parent *Context, c *Command, pre, args []string) (err error) {
+	start := time.Now()
+	a.debug("executing command", "command", c.Name, "args", args)
+	defer func() {
+		d := time.Since(start)
+		a.debug("command finished", "command", c.Name, "args", args,
+			"duration", d, "error", err)
+		if a.OnCommandComplete != nil {
+			a.OnCommandComplete(c.path, d, err)
+		}
+	}()
+
 	defer func() {
 		if r := recover(); r != nil {
-			if a.config.panicHandler != nil {
-				a.config.panicHandler(r)
-			} else {
-				err = fmt.Errorf("panic: %v", r)
-			}
+			err = a.panicError(r, debug.Stack())
 		}
 	}()
 
-	return a.execute(c, args)
+	return a.execute(parent, c, pre, args)
+}
+
+// panicError hands a recovered panic to the configured handler and
+// returns the error the command fails with, nil if a handler took it.
+func (a *App) panicError(r any, stack []byte) error {
+	switch {
+	case a.config.panicStack != nil:
+		a.config.panicStack(r, stack)
+	case a.config.panicHandler != nil:
+		a.config.panicHandler(r)
+	case a.config.debug || a.config.trace:
+		return fmt.Errorf("panic: %v\n\n%s", r, truncateStack(stack))
+	default:
+		return fmt.Errorf("panic: %v", r)
+	}
+	return nil
+}
+
+// maxPanicStack caps the stack trace attached to panic errors.
+const maxPanicStack = 4 << 10
+
+// truncateStack shortens stack to maxPanicStack bytes, cutting at a
+// line boundary.
+func truncateStack(stack []byte) []byte {
+	if len(stack) <= maxPanicStack {
+		return stack
+	}
+	cut := bytes.LastIndexByte(stack[:maxPanicStack], '\n')
+	if cut < 0 {
+		cut = maxPanicStack
+	}
+	return append(stack[:cut:cut], "\n\t..."...)
+}
+
+// countRun records one execution of the command at path.
+func (a *App) countRun(path string) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if a.stats == nil {
+		a.stats = make(map[string]int)
+	}
+	a.stats[path]++
+}
+
+// RunStats returns how often each command ran since the app was
+// created or last Reset, keyed by command path ("" for the root
+// command). Nested Exec calls and REPL lines count too. The map is a
+// copy.
+func (a *App) RunStats() map[string]int {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	return maps.Clone(a.stats)
+}
+
+// Reset clears per-session state kept by the app, currently the
+// RunStats counters.
+func (a *App) Reset() {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	a.stats = nil
 }
 
+// Parse resolves args against the command tree and executes the
+// matching command.
+//
+// Parse may be called from several goroutines at once, for example by
+// a server running commands for its clients, once the App is set up:
+// registering commands, flags and plugins must be done before. Each
+// call parses into its own copies of the typed flags, so the values
+// one run sees never leak into another, and the declared flags keep
+// their defaults. Shared are what the App and the caller own: plain
+// flags added to Command.Flags, variables bound with StringVar and
+// friends or BindStruct, App.Out and App.Err (ExecCapture replaces
+// them while running) and App.In. The Store is safe for concurrent
+// use.
 func (a *App) Parse(args []string) error {
-	a.debugf("bug report: https://github.com/fyrna/cli/issues")
+	if a.config.needAction {
+		if err := errors.Join(a.missingActions()...); err != nil {
+			return err
+		}
+	}
+	if a.config.respFiles {
+		var err error
+		if args, err = a.expandResponseFiles(args); err != nil {
+			return err
+		}
+	}
+	for _, fn := range a.transforms {
+		var err error
+		if args, err = fn(args); err != nil {
+			return err
+		}
+	}
+	return a.parse(nil, args)
+}
+
+// BeforeParse registers fn to rewrite the argument list before Parse
+// resolves it, e.g. to inject a default subcommand or translate old
+// spellings. Transformers are chained in registration order, each
+// getting the previous one's result; response files are already
+// expanded. An error aborts Parse. Context.Exec does not run them.
+func (a *App) BeforeParse(fn ArgsTransformer) *App {
+	a.transforms = append(a.transforms, fn)
+	return a
+}
+
+// missingActions reports every leaf command without an Action, by
+// path. Commands with subcommands may omit it, they act as groups.
+func (a *App) missingActions() []error {
+	var errs []error
+	var walk func(n *node, prefix string)
+	walk = func(n *node, prefix string) {
+		for _, name := range sortedKeys(n.child) {
+			child := n.child[name]
+			full := strings.TrimSpace(prefix + " " + name)
+			if child.cmd != nil && child.cmd.Action == nil && len(child.child) == 0 {
+				errs = append(errs, &ErrNoAction{Path: full})
+			}
+			walk(child, full)
+		}
+	}
+	walk(a.root, "")
+	return errs
+}
+
+// parse is Parse with the invoking Context, if any, so nested
+// Context.Exec calls can be tracked.
+func (a *App) parse(parent *Context, args []string) error {
+	a.debug("bug report: https://github.com/fyrna/cli/issues")
+
+	if a.root.cmd == nil && a.helpFlagAction == nil && a.rootHelpJSON(args) {
+		return a.HelpJSON(a.runOut())
+	}
+
+	// global flags may come before the command path
+	pre, tail := a.splitGlobals(args)
+	if len(tail) == 0 && a.root.cmd == nil {
+		if a.versionRequested(pre) {
+			return a.printVersion(a.runOut())
+		}
+		args = nil // only globals, e.g. `app --help`
+	}
 
 	if len(args) == 0 {
-		a.debugf("no root command set yet")
+		a.debug("no root command set yet")
 
 		// 1) root command
 		if a.root.cmd != nil {
-			a.debugf("executing root command override")
-			return a.safeExecute(a.root.cmd, []string{rootCommandPath})
+			a.debug("executing root command override")
+			return a.safeExecute(parent, a.root.cmd, nil, nil)
 		}
 
 		// 2) help command
 		h, ok := a.root.child["help"]
 		if ok && h.cmd != nil {
-			a.debugf("falling back to help command")
-			return a.safeExecute(h.cmd, []string{"help"})
+			a.debug("falling back to help command")
+			return a.safeExecute(parent, h.cmd, nil, []string{"help"})
 		}
 
 		// 3) default
-		a.debugf("showing default root help")
-		return a.PrintRootHelp()
+		a.debug("showing default root help")
+		return a.writeRootHelp(a.runOut())
 	}
 
 	// Check if the first argument is a known command
 	// and NOT a root command
-	n, _ := a.root.get(args)
+	// (args are re-sliced so the leaf name comes first, followed
+	// by everything after the command path)
+	n, rest, err := a.root.resolve(tail, a.config.abbrev, a.config.fold)
+	if err != nil {
+		return err
+	}
+	a.logf(2, "resolved %q to %q", args, rest)
 	if n.cmd != nil && n.cmd.Name != "" {
-		return a.safeExecute(n.cmd, args)
+		// a command group has no action of its own, so a leftover
+		// word is a mistyped subcommand rather than an argument
+		if n.cmd.Action == nil && len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+			return a.notFound(n.cmd, rest)
+		}
+		return a.safeExecute(parent, n.cmd, pre, append([]string{n.cmd.Name}, rest...))
 	}
 
 	// If we get here, it's either:
 	// 1. A global flag
 	// 2. An unknown command
 	if a.root.cmd != nil && strings.HasPrefix(args[0], "-") {
-		return a.safeExecute(a.root.cmd, args)
+		return a.safeExecute(parent, a.root.cmd, pre, tail)
 	}
 
 	// Otherwise show command not found
-	return a.OnNotFound(&Context{App: a}, args[0])
+	return a.notFound(nil, tail)
+}
+
+// splitGlobals splits the global flags at the front of args, with
+// their values, from the rest. It stops at the first token that is
+// not a known global flag.
+func (a *App) splitGlobals(args []string) (pre, tail []string) {
+	if len(a.globals) == 0 {
+		return nil, args
+	}
+
+	fs := a.newFlagSet("")
+	for _, g := range a.globals {
+		g.apply(fs)
+	}
+
+	i := 0
+	for i < len(args) {
+		tok := args[i]
+		if len(tok) < 2 || tok[0] != '-' || tok == "--" {
+			break
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(tok, "-"), "=")
+		f := lookupFlag(fs, name, a.config.fold)
+		if f == nil && !hasValue {
+			f = stackedCount(fs, name) // -vvv
+		}
+		if f == nil {
+			break
+		}
+
+		i++
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); hasValue || ok && b.IsBoolFlag() {
+			continue
+		}
+		if i < len(args) {
+			i++ // value in the next token
+		}
+	}
+	return args[:i], args[i:]
+}
+
+// notFound reports rest[0] as unknown below cmd, nil for the top level.
+// The command's NotFound handler, then the fallback, get the first say.
+func (a *App) notFound(cmd *Command, rest []string) error {
+	ctx := &Context{App: a, Cmd: cmd, RawArgs: rest}
+	if h := a.subtreeNotFound(cmd); h != nil {
+		err := h(ctx, rest[0])
+		if !errors.Is(err, ErrNotHandled) {
+			return err
+		}
+		a.debug("not-found handler declined", "command", rest[0])
+	}
+	if a.fallback != nil {
+		err := a.fallback(ctx, rest[0], rest[1:])
+		if !errors.Is(err, ErrNotHandled) {
+			return err
+		}
+		a.debug("fallback declined", "command", rest[0])
+	}
+	return a.OnNotFound(ctx, rest[0])
+}
+
+// subtreeNotFound returns the NotFound handler of cmd or of its nearest
+// ancestor that has one, nil if there is none.
+func (a *App) subtreeNotFound(cmd *Command) NotFoundHandler {
+	if cmd == nil || cmd.path == rootCommandPath {
+		return nil
+	}
+	parts := strings.Split(cmd.path, " ")
+	for i := len(parts); i > 0; i-- {
+		if n, ok := a.lookupNode(strings.Join(parts[:i], " ")); ok && n.cmd != nil && n.cmd.notFound != nil {
+			return n.cmd.notFound
+		}
+	}
+	return nil
+}
+
+// SetFallback installs fn to handle unknown commands, e.g. by running
+// an "app-<name>" executable git-style. It gets the unknown name and
+// the arguments after it. It runs before OnNotFound, and so before any
+// suggestions OnNotFound makes; return ErrNotHandled to pass the
+// command on to OnNotFound.
+func (a *App) SetFallback(fn func(c *Context, name string, args []string) error) *App {
+	a.fallback = fn
+	return a
+}
+
+// Execute parses and runs args like Parse, then hands any error to
+// OnError. The error is returned rather than exiting, so the app can
+// be embedded in a larger program or driven from tests. Plugins stay
+// installed for further calls; see App.Shutdown.
+func (a *App) Execute(args []string) error {
+	err := a.Parse(args)
+	if err != nil {
+		a.handleError(err)
+	}
+	return err
+}
+
+// BeforeCommand registers fn to run before every command, ahead of its
+// Before hook, once its flags are parsed; c is the resolved command.
+// An error stops the command and is its result. Hooks run in the order
+// registered and are meant for plugins that observe all commands, such
+// as for logging or metrics, see AfterCommand.
+func (a *App) BeforeCommand(fn func(ctx *Context, c *Command) error) *App {
+	a.beforeCmd = append(a.beforeCmd, fn)
+	return a
+}
+
+// AfterCommand registers fn to run when a command that got past flag
+// parsing has finished, after its After and OnSuccess hooks. err is
+// what the command returns, nil on success; a panic is passed as an
+// error too. It is called even when a BeforeCommand hook failed.
+//
+//	app.AfterCommand(func(c *cli.Context, cmd *cli.Command, err error) {
+//		if err != nil {
+//			log.Printf("%s failed: %v", c.Path(), err)
+//		}
+//	})
+func (a *App) AfterCommand(fn func(ctx *Context, c *Command, err error)) *App {
+	a.afterCmd = append(a.afterCmd, fn)
+	return a
 }
 
-// Run executes the application with os.Args and handles errors
+// ExecuteResult is like Execute and also returns the value the command
+// passed to Context.SetResult, nil if it set none. When a command runs
+// others with Exec, the last value set wins. Unlike Parse, concurrent
+// calls on one App share the result.
+//
+//	v, err := app.ExecuteResult([]string{"sum", "1", "2"})
+func (a *App) ExecuteResult(args []string) (any, error) {
+	a.setResult(nil)
+	err := a.Execute(args)
+	return a.setResult(nil), err
+}
+
+// setResult stores v as the result and returns the previous one.
+func (a *App) setResult(v any) any {
+	a.resultMu.Lock()
+	defer a.resultMu.Unlock()
+	old := a.result
+	a.result = v
+	return old
+}
+
+// handleError passes err to OnError outside of any command.
+func (a *App) handleError(err error) {
+	ctx := &Context{App: a}
+	if err2 := a.OnError(ctx, err); err2 != nil {
+		a.debug("OnError returned", "error", err2)
+	}
+}
+
+// Run executes the application with os.Args, handles errors and shuts
+// the plugins down, see App.Shutdown. It exits with the code of an
+// ExitError, 130 on ErrInterrupted, 2 on a UsageError and 1 on any
+// other error.
 func (a *App) Run() {
-	if err := a.Parse(os.Args[1:]); err != nil {
-		ctx := &Context{App: a}
-		if err2 := a.OnError(ctx, err); err2 != nil {
-			a.debugf("OnError returned: %v", err2)
+	if err := a.finish(a.Execute(os.Args[1:])); err != nil {
+		var ee *ExitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.Code)
+		}
+		if errors.Is(err, ErrInterrupted) {
+			os.Exit(130)
+		}
+		var ue *UsageError
+		if errors.As(err, &ue) {
+			os.Exit(2)
 		}
 		os.Exit(1)
 	}
@@ -394,19 +1187,71 @@ func (a *App) WalkCommands(fn func(path string, cmd *Command)) {
 	walk(a.root, "")
 }
 
-// LookupCommand returns the command at the given path, if any.
-// Returned *Command is read-only.
-func (a *App) LookupCommand(path string) (*Command, bool) {
-	parts := strings.Split(path, " ")
-	n, rest := a.root.get(parts)
-
-	if len(rest) == 0 && n.cmd != nil {
+// Lookup returns the command registered at path, e.g. "server start".
+// It reports false unless every word of path matches, so a partial
+// match like "server bogus" returns false. The empty path refers to
+// the root command. Returned *Command is read-only.
+func (a *App) Lookup(path string) (*Command, bool) {
+	n, ok := a.lookupNode(path)
+	if ok && n.cmd != nil {
 		return n.cmd, true
 	}
 
 	return nil, false
 }
 
+// LookupCommand is the same as Lookup.
+func (a *App) LookupCommand(path string) (*Command, bool) {
+	return a.Lookup(path)
+}
+
+// lookupNode returns the tree node at path, "" being the root.
+func (a *App) lookupNode(path string) (*node, bool) {
+	if path == rootCommandPath {
+		return a.root, true
+	}
+
+	n, rest := a.root.get(strings.Split(path, " "))
+	return n, len(rest) == 0
+}
+
+// inheritedFlags returns the flags c gets from elsewhere: persistent
+// flags of its ancestors, nearest first, then the globals. A name taken
+// by a nearer flag hides farther ones.
+func (a *App) inheritedFlags(c *Command) []Flag {
+	var chain []*Command
+	if c != a.root.cmd {
+		parts := strings.Split(c.path, " ")
+		for i := len(parts) - 1; i > 0; i-- {
+			if n, ok := a.lookupNode(strings.Join(parts[:i], " ")); ok && n.cmd != nil {
+				chain = append(chain, n.cmd)
+			}
+		}
+		if a.root.cmd != nil {
+			chain = append(chain, a.root.cmd)
+		}
+	}
+
+	var out []Flag
+	seen := make(map[string]bool)
+	add := func(ff []Flag) {
+		for _, f := range ff {
+			if fi, ok := f.(FlagInfo); ok {
+				if seen[fi.GetName()] {
+					continue
+				}
+				seen[fi.GetName()] = true
+			}
+			out = append(out, f)
+		}
+	}
+	for _, anc := range chain {
+		add(anc.persistent)
+	}
+	add(a.globals)
+	return out
+}
+
 // GlobalFlagsInfo returns a read-only snapshot of global flags.
 func (a *App) GlobalFlagsInfo() []FlagInfo {
 	out := make([]FlagInfo, 0, len(a.globals))
@@ -416,6 +1261,18 @@ func (a *App) GlobalFlagsInfo() []FlagInfo {
 	return out
 }
 
+// FlagInfos returns the local flags of the command, as declared
+// through the Flags option, in declaration order.
+func (c *Command) FlagInfos() []FlagInfo {
+	out := make([]FlagInfo, 0, len(c.flags))
+	for _, f := range c.flags {
+		if fi, ok := f.(FlagInfo); ok {
+			out = append(out, fi)
+		}
+	}
+	return out
+}
+
 // EachFlagInfo iterates over the local flags of the command via a read-only interface.
 func (c *Command) EachFlagInfo(fn func(FlagInfo)) {
 	if c.Flags == nil {
@@ -0,0 +1,659 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestApp returns an app writing to buffers instead of the terminal.
+func newTestApp(t *testing.T, opts ...ConfigOption) (app *App, out, errOut *bytes.Buffer) {
+	t.Helper()
+	app = New("app", opts...)
+	out, errOut = new(bytes.Buffer), new(bytes.Buffer)
+	app.Out, app.Err = out, errOut
+	return app, out, errOut
+}
+
+// mustCommand registers a command and fails the test on error.
+func mustCommand(t *testing.T, app *App, path string, fn func(*Context) error, opts ...CommandOption) {
+	t.Helper()
+	if _, err := app.Command(path, fn, opts...); err != nil {
+		t.Fatalf("Command(%q): %v", path, err)
+	}
+}
+
+func TestParseConcurrent(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(String("region").Default("us"))
+
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	mustCommand(t, app, "greet", func(c *Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[c.Args().Get(0)] = c.GetString("name") + "/" + c.GetString("region") + "/" + c.GlobalString("region")
+		return nil
+	}, Flags(String("name").Default("world")))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprint(i)
+			args := []string{"greet", "--name", "n" + id, id}
+			if i%2 == 0 {
+				args = []string{"greet", "--region", "r" + id, id}
+			}
+			if err := app.Parse(args); err != nil {
+				t.Errorf("Parse(%q): %v", args, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range 20 {
+		id := fmt.Sprint(i)
+		want := "n" + id + "/us/us"
+		if i%2 == 0 {
+			want = "world/r" + id + "/r" + id
+		}
+		if got := seen[id]; got != want {
+			t.Errorf("run %s saw %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestParseDoesNotLeakValues(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got []string
+	mustCommand(t, app, "show", func(c *Context) error {
+		got = append(got, c.GetString("name")+":"+c.Source("name").String())
+		return nil
+	}, Flags(String("name").Default("world")))
+
+	for _, args := range [][]string{{"show", "--name", "bob"}, {"show"}} {
+		if err := app.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"bob:cli", "world:default"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGlobalsBeforeCommandPath(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(Bool("verbose", "v"), String("region").Default("us"))
+
+	var verbose bool
+	var region string
+	var args []string
+	run := func(c *Context) error {
+		verbose, region, args = c.GetBool("verbose"), c.GetString("region"), c.Args()
+		return nil
+	}
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", run)
+	mustCommand(t, app, "wrap", run, RawArgs())
+
+	tests := []struct {
+		args    []string
+		verbose bool
+		region  string
+		rest    string
+	}{
+		{[]string{"--verbose", "server", "start", "x"}, true, "us", "[x]"},
+		{[]string{"server", "start", "--verbose", "x"}, true, "us", "[x]"},
+		{[]string{"-v", "--region", "eu", "server", "start"}, true, "eu", "[]"},
+		{[]string{"--verbose", "wrap", "ls", "-l"}, true, "us", "[ls -l]"},
+		{[]string{"--region=eu", "wrap", "--verbose"}, false, "eu", "[--verbose]"},
+		{[]string{"wrap", "--region", "eu"}, false, "us", "[--region eu]"},
+	}
+	for _, tt := range tests {
+		if err := app.Parse(tt.args); err != nil {
+			t.Errorf("Parse(%q): %v", tt.args, err)
+			continue
+		}
+		if verbose != tt.verbose || region != tt.region || fmt.Sprint(args) != tt.rest {
+			t.Errorf("Parse(%q): verbose=%v region=%q args=%q, want %v %q %s",
+				tt.args, verbose, region, args, tt.verbose, tt.region, tt.rest)
+		}
+	}
+}
+
+func TestRawArgs(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	var got []string
+	mustCommand(t, app, "plugin", nil)
+	mustCommand(t, app, "plugin run", func(c *Context) error {
+		got = c.Args()
+		return nil
+	}, RawArgs(), Flags(Bool("force")))
+
+	args := []string{"plugin", "run", "--help", "-x=1", "--force", "--", "-h", "foo"}
+	if err := app.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if want := args[2:]; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if out.Len() != 0 {
+		t.Errorf("printed %q, want nothing", out)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(*Context) error { return nil })
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"server start", true},
+		{"server", true},
+		{"start", false},
+		{"server bogus", false},
+		{"server start now", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		cmd, ok := app.Lookup(tt.path)
+		if ok != tt.want || ok && cmd.path != tt.path {
+			t.Errorf("Lookup(%q) = %v, %v, want %v", tt.path, cmd, ok, tt.want)
+		}
+	}
+
+	app.Root(func(*Context) error { return nil })
+	if _, ok := app.Lookup(""); !ok {
+		t.Error(`Lookup("") found no root command`)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(*Context) error { return nil })
+	app.Root(func(*Context) error { return nil })
+
+	if app.Remove("server bogus") {
+		t.Error(`Remove("server bogus") = true`)
+	}
+	if !app.Remove("server") {
+		t.Fatal(`Remove("server") = false`)
+	}
+	if app.Remove("server") {
+		t.Error(`second Remove("server") = true`)
+	}
+
+	var nf *ErrCommandNotFound
+	if err := app.Parse([]string{"server", "start"}); !errors.As(err, &nf) || nf.Name != "server" {
+		t.Errorf("Parse after Remove: error = %v, want server not found", err)
+	}
+
+	if !app.Remove("") || app.RootExists() {
+		t.Error(`Remove("") left the root command`)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	say := func(s string) func(*Context) error {
+		return func(c *Context) error {
+			fmt.Fprint(c.Out(), s)
+			return nil
+		}
+	}
+	mustCommand(t, app, "server", say("base"))
+	mustCommand(t, app, "server start", say("start"))
+
+	var dup *ErrDuplicateCommand
+	if _, err := app.Command("server", say("again")); !errors.As(err, &dup) || dup.Path != "server" {
+		t.Errorf("duplicate Command error = %v, want ErrDuplicateCommand", err)
+	}
+	mustCommand(t, app, "server", say("plugin"), Override())
+
+	for args, want := range map[string]string{"server": "plugin", "server start": "start"} {
+		out.Reset()
+		if err := app.Parse(strings.Fields(args)); err != nil || out.String() != want {
+			t.Errorf("Parse(%q) printed %q, %v, want %q", args, out, err, want)
+		}
+	}
+}
+
+func TestExecuteReturnsError(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var handled []error
+	app.OnError = func(_ *Context, err error) error {
+		handled = append(handled, err)
+		return err
+	}
+	boom := errors.New("boom")
+	mustCommand(t, app, "fail", func(*Context) error { return boom })
+
+	if err := app.Parse([]string{"fail"}); !errors.Is(err, boom) || len(handled) != 0 {
+		t.Errorf("Parse = %v with %d OnError calls, want boom and none", err, len(handled))
+	}
+	if err := app.Execute([]string{"fail"}); !errors.Is(err, boom) || len(handled) != 1 {
+		t.Errorf("Execute = %v with %d OnError calls, want boom and one", err, len(handled))
+	}
+	if err := app.Execute(nil); err != nil || len(handled) != 1 {
+		t.Errorf("Execute(nil) = %v with %d OnError calls", err, len(handled))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var afterErr error
+	mustCommand(t, app, "fast", func(*Context) error { return nil }, Timeout(time.Second))
+	mustCommand(t, app, "slow", func(c *Context) error {
+		select {
+		case <-c.Ctx.Done():
+			return c.Ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}, Timeout(10*time.Millisecond), After(func(c *Context) error {
+		afterErr = c.Ctx.Err()
+		return nil
+	}))
+
+	if err := app.Parse([]string{"fast"}); err != nil {
+		t.Errorf("fast: %v", err)
+	}
+	err := app.Parse([]string{"slow"})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), `command "slow" timed out after 10ms`) {
+		t.Errorf("slow: error = %v, want a timeout", err)
+	}
+	if afterErr != nil {
+		t.Errorf("After saw a cancelled Ctx: %v", afterErr)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	app, _, errOut := newTestApp(t)
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(*Context) error { return nil })
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"bogus"}, "command bogus not found"},
+		{[]string{"server", "bogus"}, `unknown subcommand "bogus" for "server"`},
+	} {
+		errOut.Reset()
+		err := app.Execute(tt.args)
+		var ee *ExitError
+		var nf *ErrCommandNotFound
+		if !errors.As(err, &ee) || ee.Code != 127 || !errors.As(err, &nf) {
+			t.Errorf("Execute(%q) = %v, want exit code 127 and ErrCommandNotFound", tt.args, err)
+		}
+		if !strings.Contains(errOut.String(), tt.want) {
+			t.Errorf("Execute(%q) printed %q, want %q", tt.args, errOut, tt.want)
+		}
+	}
+
+	app.OnNotFound = func(*Context, string) error { return nil }
+	if err := app.Execute([]string{"bogus"}); err != nil {
+		t.Errorf("with a nil-returning OnNotFound: %v", err)
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxAbbreviations(true))
+	var ran string
+	run := func(c *Context) error {
+		ran = c.Path() + " " + strings.Join(c.Args(), " ")
+		return nil
+	}
+	for _, path := range []string{"server", "server start", "server status", "serve", "search"} {
+		mustCommand(t, app, path, run)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+		err  string
+	}{
+		{[]string{"serve"}, "serve ", ""},
+		{[]string{"sea", "x"}, "search x", ""},
+		{[]string{"server", "sta"}, "", `ambiguous command "sta": could be start, status`},
+		{[]string{"server", "star", "--", "-s"}, "server start -s", ""},
+		{[]string{"ser"}, "", `ambiguous command "ser": could be serve, server`},
+		{[]string{"deploy"}, "", "command deploy not found"},
+	}
+	for _, tt := range tests {
+		ran = ""
+		err := app.Parse(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || ran != tt.want {
+			t.Errorf("Parse(%q) ran %q, %v, want %q", tt.args, ran, err, tt.want)
+		}
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Root(func(*Context) error { return nil })
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(c *Context) error { return nil })
+	mustCommand(t, app, "restart", func(c *Context) error {
+		return errors.Join(c.Exec("server start"), c.Exec("server start"))
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := app.Parse([]string{"restart"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := app.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := app.RunStats()
+	if want := map[string]int{"": 1, "restart": 10, "server start": 20}; !maps.Equal(stats, want) {
+		t.Errorf("RunStats = %v, want %v", stats, want)
+	}
+	stats["restart"] = 0
+	if app.RunStats()["restart"] != 10 {
+		t.Error("changing the returned map changed the stats")
+	}
+
+	app.Reset()
+	if stats := app.RunStats(); len(stats) != 0 {
+		t.Errorf("RunStats after Reset = %v", stats)
+	}
+}
+
+func TestSubtreeNotFound(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got []string
+	handler := func(owner string) NotFoundHandler {
+		return func(c *Context, name string) error {
+			if name == "pass" {
+				return ErrNotHandled
+			}
+			got = append(got, owner+":"+c.Cmd.path+":"+name+":"+strings.Join(c.RawArgs, " "))
+			return nil
+		}
+	}
+	app.OnNotFound = func(c *Context, name string) error {
+		got = append(got, "app:"+name)
+		return nil
+	}
+	mustCommand(t, app, "db", nil, NotFound(handler("db")))
+	mustCommand(t, app, "db migrate", nil)
+	mustCommand(t, app, "db migrate up", func(*Context) error { return nil })
+	mustCommand(t, app, "db user", nil, NotFound(handler("user")))
+	mustCommand(t, app, "cache", nil)
+
+	for _, args := range [][]string{
+		{"db", "drop", "x"},
+		{"db", "migrate", "down"},
+		{"db", "user", "rm"},
+		{"db", "pass"},
+		{"cache", "clear"},
+		{"bogus"},
+	} {
+		if err := app.Parse(args); err != nil {
+			t.Errorf("Parse(%q): %v", args, err)
+		}
+	}
+	want := []string{
+		"db:db:drop:drop x",
+		"db:db migrate:down:down",
+		"user:db user:rm:rm",
+		"app:pass",
+		"app:clear",
+		"app:bogus",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxCaseInsensitive(true))
+	app.Flags(Bool("verbose"))
+	var got string
+	run := func(c *Context) error {
+		got = fmt.Sprintf("%s %v %s %q", c.Path(), c.GetBool("verbose"), c.GetString("name"), []string(c.Args()))
+		return nil
+	}
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", run, Flags(String("name")))
+	mustCommand(t, app, "deploy", run)
+	mustCommand(t, app, "Deploy", run) // exact spelling wins
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"SERVER", "Start", "--NAME", "MixedCase", "Arg"}, `server start false MixedCase ["Arg"]`},
+		{[]string{"--VERBOSE", "server", "START", "--Name=V"}, `server start true V []`},
+		{[]string{"deploy"}, `deploy false  []`},
+		{[]string{"Deploy"}, `Deploy false  []`},
+	}
+	for _, tt := range tests {
+		got = ""
+		if err := app.Parse(tt.args); err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.args, got, err, tt.want)
+		}
+	}
+
+	strict, _, _ := newTestApp(t)
+	mustCommand(t, strict, "server", run)
+	if err := strict.Parse([]string{"SERVER"}); err == nil {
+		t.Error("SERVER resolved without FluxCaseInsensitive")
+	}
+}
+
+func TestDeprecatedCommand(t *testing.T) {
+	opts := []CommandOption{Short("list items"), Deprecated(`use "get" instead`), DeprecatedSince("1.2"), RemovedIn("2.0")}
+	const details = `since 1.2 and will be removed in 2.0: use "get" instead`
+
+	app, out, errOut := newTestApp(t)
+	ran := false
+	mustCommand(t, app, "list", func(*Context) error { ran = true; return nil }, opts...)
+	if err := app.Parse([]string{"list"}); err != nil || !ran {
+		t.Fatalf("list: ran %v, error %v", ran, err)
+	}
+	if want := `warning: command "list" is deprecated ` + details + "\n"; errOut.String() != want {
+		t.Errorf("warning = %q, want %q", errOut, want)
+	}
+
+	if err := app.Parse([]string{"list", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "This command is deprecated " + details + "."; !strings.Contains(out.String(), want) {
+		t.Errorf("help misses %q:\n%s", want, out)
+	}
+	out.Reset()
+	if err := app.Parse([]string{"--help"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "(deprecated) list items") {
+		t.Errorf("listing misses the deprecation:\n%s", out)
+	}
+
+	app, _, errOut = newTestApp(t, FluxDeprecatedAsErrors(true))
+	ran = false
+	mustCommand(t, app, "list", func(*Context) error { ran = true; return nil }, opts...)
+	var derr *ErrDeprecatedCommand
+	if err := app.Parse([]string{"list"}); !errors.As(err, &derr) || derr.Since != "1.2" || derr.RemovedIn != "2.0" {
+		t.Errorf("with FluxDeprecatedAsErrors: error = %v, want ErrDeprecatedCommand", err)
+	}
+	if ran {
+		t.Error("with FluxDeprecatedAsErrors: the action ran")
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("with FluxDeprecatedAsErrors: warned %q", errOut)
+	}
+}
+
+func TestAddCommands(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "status", func(*Context) error { return nil })
+
+	var ran []string
+	run := func(c *Context) error { ran = append(ran, c.Path()); return nil }
+	err := app.AddCommands(
+		&Command{Name: "db migrate up", Action: run},
+		&Command{Name: "db migrate", Action: run},
+		&Command{Name: "status", Action: run},
+		&Command{Name: "db", Short: "database tools", Action: run},
+		&Command{Name: "cache clear", Action: run},
+		&Command{Name: " ", Action: run},
+	)
+	var dup *ErrDuplicateCommand
+	if !errors.As(err, &dup) || dup.Path != "status" {
+		t.Errorf("error = %v, want ErrDuplicateCommand for status", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "command without a name") {
+		t.Errorf("error = %v, want the nameless command reported too", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), `command "cache clear": parent "cache" is not registered`) {
+		t.Errorf("error = %v, want the orphan reported too", err)
+	}
+
+	for _, args := range [][]string{{"db"}, {"db", "migrate"}, {"db", "migrate", "up"}} {
+		if err := app.Parse(args); err != nil {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+	if want := []string{"db", "db migrate", "db migrate up"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+	for _, path := range []string{"migrate", "up", "clear"} {
+		if _, ok := app.Lookup(path); ok {
+			t.Errorf("%q was registered at the top level", path)
+		}
+	}
+	if _, ok := app.Lookup("db migrate up"); !ok {
+		t.Error(`Lookup("db migrate up") found nothing`)
+	}
+}
+
+func TestExecuteResult(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.OnError = func(_ *Context, err error) error { return err }
+	mustCommand(t, app, "sum", func(c *Context) error {
+		total := 0
+		for _, a := range c.Args() {
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		c.SetResult(total)
+		return nil
+	})
+	mustCommand(t, app, "noop", func(*Context) error { return nil })
+	mustCommand(t, app, "twice", func(c *Context) error { return c.Exec("sum", "1", "1") })
+	boom := errors.New("boom")
+	mustCommand(t, app, "partial", func(c *Context) error { c.SetResult("half"); return boom })
+
+	tests := []struct {
+		args []string
+		want any
+		err  error
+	}{
+		{[]string{"sum", "1", "2", "3"}, 6, nil},
+		{[]string{"noop"}, nil, nil},
+		{[]string{"twice"}, 2, nil},
+		{[]string{"partial"}, "half", boom},
+	}
+	for _, tt := range tests {
+		got, err := app.ExecuteResult(tt.args)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ExecuteResult(%q) = %v, %v, want %v, %v", tt.args, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCommandHooks(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var log []string
+	step := func(name string, err error) func(*Context) error {
+		return func(*Context) error { log = append(log, name); return err }
+	}
+	var gotCmd *Command
+	var gotErr error
+	app.BeforeCommand(func(c *Context, cmd *Command) error {
+		log = append(log, "before command "+cmd.path)
+		if c.GetBool("deny") {
+			return errors.New("denied")
+		}
+		return nil
+	})
+	app.AfterCommand(func(_ *Context, cmd *Command, err error) {
+		log = append(log, "after command")
+		gotCmd, gotErr = cmd, err
+	})
+
+	boom := errors.New("boom")
+	mustCommand(t, app, "ok", step("action", nil), Flags(Bool("deny")),
+		Before(step("before", nil)), After(step("after", nil)), OnSuccess(step("success", nil)))
+	mustCommand(t, app, "fail", step("action", boom),
+		Before(step("before", nil)), After(step("after", nil)), OnSuccess(step("success", nil)))
+	mustCommand(t, app, "panic", func(*Context) error { panic("oops") })
+
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{[]string{"ok"}, []string{"before command ok", "before", "action", "after", "success", "after command"}, ""},
+		{[]string{"fail"}, []string{"before command fail", "before", "action", "after", "after command"}, "boom"},
+		{[]string{"ok", "--deny"}, []string{"before command ok", "after command"}, "denied"},
+		{[]string{"panic"}, []string{"before command panic", "after command"}, "panic: oops"},
+	}
+	for _, tt := range tests {
+		log, gotCmd, gotErr = nil, nil, nil
+		err := app.Parse(tt.args)
+		if !slices.Equal(log, tt.want) {
+			t.Errorf("%q ran %q, want %q", tt.args, log, tt.want)
+		}
+		if gotCmd == nil || gotCmd.path != tt.args[0] {
+			t.Errorf("%q: AfterCommand got command %v", tt.args, gotCmd)
+		}
+		if tt.wantErr == "" {
+			if err != nil || gotErr != nil {
+				t.Errorf("%q: error %v, AfterCommand got %v", tt.args, err, gotErr)
+			}
+			continue
+		}
+		if err == nil || gotErr != err || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: error %v, AfterCommand got %v, want %q for both", tt.args, err, gotErr, tt.wantErr)
+		}
+	}
+
+	log = nil
+	if err := app.Parse([]string{"ok", "--bogus"}); err == nil || len(log) != 0 {
+		t.Errorf("a flag error: error %v, ran %q", err, log)
+	}
+}
@@ -1,9 +1,16 @@
 package cli
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Context carries request-scoped data accross Before, Action, and After hooks.
@@ -12,6 +19,51 @@ type Context struct {
 	Cmd     *Command // The command currently being executed.
 	RawArgs []string // Unprocessed arguments (including name).
 	Flags   *flag.FlagSet
+
+	// Ctx carries cancellation and deadlines, see Timeout. It is
+	// inherited by Exec and never nil in Before, Action and After.
+	Ctx context.Context
+
+	depth int // Context.Exec nesting level, 0 for top-level invocation
+
+	copies map[Flag]Flag // declared flags to this run's copies, see bindFlags
+	out    io.Writer     // App.Out as filtered for this run, see Out
+
+	inherit map[string][]string // global values for runs it starts, see ExecInherit
+}
+
+// nextDepth returns the nesting level for a Context spawned by c.
+// A nil receiver denotes a top-level invocation.
+func (c *Context) nextDepth() int {
+	if c == nil {
+		return 0
+	}
+	return c.depth + 1
+}
+
+// baseCtx returns the context.Context a Context spawned by c inherits.
+func (c *Context) baseCtx() context.Context {
+	if c == nil || c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// Path returns the full path of the running command, e.g.
+// "server start", where Cmd.Name holds only "start". It is empty for
+// the root command and outside of a command.
+func (c *Context) Path() string {
+	if c.Cmd == nil {
+		return ""
+	}
+	return c.Cmd.path
+}
+
+// SetResult records v as the command's result for a caller using
+// App.ExecuteResult, e.g. to embed the app as a library. It replaces
+// any earlier result; Run and Execute ignore it.
+func (c *Context) SetResult(v any) {
+	c.App.setResult(v)
 }
 
 // Exec re-parses the supplied path and arguments as if they came from the real
@@ -27,26 +79,225 @@ type Context struct {
 //		  c.Exec("greet")
 //		  return nil
 //	 })
+//
+// Exec starts a fresh invocation: flags parsed by the current command,
+// globals included, are not carried over. Use ExecInherit for that.
+//
+// path is split with Tokenize, so it may carry quoted arguments too:
+// c.Exec(`greet "John Doe"`). Arguments in args are passed as is.
+//
+// Nested calls are limited by FluxMaxDepth; exceeding it returns an
+// error instead of recursing forever.
 func (c *Context) Exec(path string, args ...string) error {
-	parts := append(strings.Split(path, " "), args...)
-	return c.App.Parse(parts)
+	if c.depth >= c.App.config.maxDepth {
+		return fmt.Errorf("maximum command nesting depth exceeded")
+	}
+	parts, err := Tokenize(path)
+	if err != nil {
+		return err
+	}
+	return c.App.parse(c, append(parts, args...))
 }
 
-func (c *Context) GetString(name string) string {
+// ExecInherit is like Exec but forwards the global flags explicitly set
+// on the current invocation. Their values are set on the invoked
+// command directly rather than added to its arguments, so positional
+// args in path and args are passed through unchanged, and a global
+// given again in args takes precedence. List flags carry all their
+// elements.
+func (c *Context) ExecInherit(path string, args ...string) error {
+	cc := *c
+	cc.inherit = c.setGlobals()
+	return cc.Exec(path, args...)
+}
+
+// ExecCapture is like Exec but collects what the invoked command writes
+// to App.Out and App.Err instead of printing it. Both writers are
+// restored afterwards, even if the command panics.
+func (c *Context) ExecCapture(path string, args ...string) (stdout, stderr string, err error) {
+	var outBuf, errBuf strings.Builder
+	out, errw := c.App.Out, c.App.Err
+	c.App.Out, c.App.Err = &outBuf, &errBuf
+	defer func() {
+		c.App.Out, c.App.Err = out, errw
+	}()
+
+	err = c.Exec(path, args...)
+	return outBuf.String(), errBuf.String(), err
+}
+
+// setGlobals returns the values of the global flags set on c by name,
+// see flagValues.
+func (c *Context) setGlobals() map[string][]string {
 	if c.Flags == nil {
-		return ""
+		return nil
 	}
-	if val := c.Flags.Lookup(name); val != nil {
-		return val.Value.String()
+
+	out := make(map[string][]string)
+	for _, gf := range c.App.globals {
+		fi, ok := gf.(FlagInfo)
+		if !ok {
+			continue
+		}
+
+		name := fi.GetName()
+		if f := c.Flags.Lookup(name); f != nil && isFlagPassed(c.Flags, name) {
+			out[name] = flagValues(f.Value)
+		}
 	}
-	return ""
+	return out
 }
 
-func (c *Context) GetBool(name string) bool {
+// flagValues returns what to pass to v.Set, once per element, to give
+// another flag of the same kind v's value: the elements of a list, the
+// "key:value" pairs of a HeaderFlag, else the value itself.
+func flagValues(v flag.Value) []string {
+	if g, ok := v.(flag.Getter); ok {
+		switch x := g.Get().(type) {
+		case []string:
+			return x
+		case [][2]string:
+			out := make([]string, len(x))
+			for i, p := range x {
+				out[i] = p[0] + ":" + p[1]
+			}
+			return out
+		}
+	}
+	return []string{v.String()}
+}
+
+// Changed reports whether the flag was set on the command line,
+// through any of its names.
+func (c *Context) Changed(name string) bool {
 	if c.Flags == nil {
 		return false
 	}
-	if val := c.Flags.Lookup(name); val != nil {
+	return isFlagPassed(c.Flags, name)
+}
+
+// FlagNames returns the long names of every flag the command accepts,
+// globals included, sorted. Short names, aliases and former names are
+// folded into the flag's long name.
+func (c *Context) FlagNames() []string {
+	return c.flagNames(func(string) bool { return true })
+}
+
+// ChangedFlags returns the long names of the flags set on the command
+// line, sorted, see Changed.
+func (c *Context) ChangedFlags() []string {
+	return c.flagNames(func(name string) bool { return isFlagPassed(c.Flags, name) })
+}
+
+func (c *Context) flagNames(keep func(name string) bool) []string {
+	if c.Flags == nil {
+		return nil
+	}
+
+	var out []string
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if name := longName(f); keep(f.Name) && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	})
+	slices.Sort(out)
+	return out
+}
+
+// Global returns the global flag registered with App.Flags under name
+// (or one of its short names and aliases), nil if there is none. Unlike
+// the Get methods it ignores command-local flags. A local flag of the
+// same name shadows the global on the command line, so the global then
+// keeps its default.
+func (c *Context) Global(name string) flag.Value {
+	return c.bound(findFlag(c.App.globals, name))
+}
+
+// findFlag returns the flag in ff called name, by long name, short
+// name or alias, nil if there is none.
+func findFlag(ff []Flag, name string) Flag {
+	for _, f := range ff {
+		fi, ok := f.(FlagInfo)
+		if !ok {
+			continue
+		}
+		if fi.GetName() == name || slices.Contains(fi.GetShort(), name) || slices.Contains(fi.GetAliases(), name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// bound returns the value of f in this run: its copy when the run
+// parsed one, else f itself, which holds the default. It is nil for a
+// nil f.
+func (c *Context) bound(f Flag) flag.Value {
+	if cp, ok := c.copies[f]; ok {
+		f = cp
+	}
+	v, _ := f.(flag.Value)
+	return v
+}
+
+// lookup finds the flag the Get methods read. The command's own
+// FlagSet comes first, then the persistent flags of its ancestors,
+// nearest first, then the globals; the first match wins. Inherited
+// flags are normally parsed into the command's FlagSet, so the later
+// steps matter where they were not, as for commands with
+// DisableFlagParsing.
+func (c *Context) lookup(name string) *flag.Flag {
+	if c.Flags != nil {
+		if f := c.Flags.Lookup(name); f != nil {
+			return f
+		}
+	}
+	if c.App == nil || c.Cmd == nil {
+		return nil
+	}
+	if v := c.bound(findFlag(c.App.inheritedFlags(c.Cmd), name)); v != nil {
+		return &flag.Flag{Name: name, Value: v, DefValue: v.String()}
+	}
+	return nil
+}
+
+// GlobalString returns the value of a global flag, see Global.
+func (c *Context) GlobalString(name string) string {
+	if v := c.Global(name); v != nil {
+		return v.String()
+	}
+	return ""
+}
+
+// GlobalBool returns the value of a global flag, see Global.
+func (c *Context) GlobalBool(name string) bool {
+	b, _ := strconv.ParseBool(c.GlobalString(name))
+	return b
+}
+
+// GlobalInt returns the value of a global flag, see Global.
+func (c *Context) GlobalInt(name string) int {
+	i, _ := strconv.Atoi(c.GlobalString(name))
+	return i
+}
+
+// DryRun reports whether --dry-run was given, see FluxDryRun.
+func (c *Context) DryRun() bool {
+	return c.GetBool("dry-run")
+}
+
+// GetString returns the value of the flag called name, "" if there is
+// none. Like all Get methods it looks in the command's own flags
+// first, then in the persistent flags of its parent commands, nearest
+// first, then in the globals, and reads the first match.
+func (c *Context) GetString(name string) string {
+	if val := c.lookup(name); val != nil {
+		return val.Value.String()
+	}
+	return ""
+}
+
+func (c *Context) GetBool(name string) bool {
+	if val := c.lookup(name); val != nil {
 		if b, err := strconv.ParseBool(val.Value.String()); err == nil {
 			return b
 		}
@@ -55,10 +306,7 @@ func (c *Context) GetBool(name string) bool {
 }
 
 func (c *Context) GetInt(name string) int {
-	if c.Flags == nil {
-		return 0
-	}
-	if val := c.Flags.Lookup(name); val != nil {
+	if val := c.lookup(name); val != nil {
 		if i, err := strconv.Atoi(val.Value.String()); err == nil {
 			return i
 		}
@@ -67,8 +315,112 @@ func (c *Context) GetInt(name string) int {
 }
 
 func (c *Context) GetFloat64(name string) float64 {
-	v, _ := strconv.ParseFloat(c.Flags.Lookup(name).Value.String(), 64)
-	return v
+	if val := c.lookup(name); val != nil {
+		if v, err := strconv.ParseFloat(val.Value.String(), 64); err == nil {
+			return v
+		}
+	}
+	return 0
+}
+
+// GetDuration returns the value of a Duration flag.
+func (c *Context) GetDuration(name string) time.Duration {
+	if val := c.lookup(name); val != nil {
+		if d, err := time.ParseDuration(val.Value.String()); err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+// GetBytes returns the byte count of a Bytes flag.
+func (c *Context) GetBytes(name string) int64 {
+	if val := c.lookup(name); val != nil {
+		if n, err := parseBytes(val.Value.String()); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// GetIP returns the address of an IP flag, nil if unset or invalid.
+func (c *Context) GetIP(name string) net.IP {
+	if val := c.lookup(name); val != nil {
+		return net.ParseIP(val.Value.String())
+	}
+	return nil
+}
+
+// GetCIDR returns the network of a CIDR flag, nil if unset or invalid.
+func (c *Context) GetCIDR(name string) *net.IPNet {
+	if val := c.lookup(name); val != nil {
+		if _, n, err := net.ParseCIDR(val.Value.String()); err == nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// GetURL returns the URL of a URLFlag, nil if unset or invalid.
+func (c *Context) GetURL(name string) *url.URL {
+	if val := c.lookup(name); val != nil && val.Value.String() != "" {
+		if u, err := url.Parse(val.Value.String()); err == nil {
+			return u
+		}
+	}
+	return nil
+}
+
+// GetPath returns the value of a Path flag, "~" expanded if enabled.
+func (c *Context) GetPath(name string) string {
+	return c.GetString(name)
+}
+
+// GetTime returns the value of a Time flag, the zero time if unset.
+func (c *Context) GetTime(name string) time.Time {
+	if val := c.lookup(name); val != nil {
+		if g, ok := val.Value.(flag.Getter); ok {
+			if t, ok := g.Get().(time.Time); ok {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// GetStringSlice returns the elements of a list flag such as
+// EnumSlice. For other flags the value is split on commas.
+func (c *Context) GetStringSlice(name string) []string {
+	val := c.lookup(name)
+	if val == nil {
+		return nil
+	}
+	if g, ok := val.Value.(flag.Getter); ok {
+		if s, ok := g.Get().([]string); ok {
+			return s
+		}
+	}
+
+	var out []string
+	for _, e := range strings.Split(val.Value.String(), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// GetPairs returns the key/value pairs of a HeaderFlag in the order
+// they were given, duplicate keys included.
+func (c *Context) GetPairs(name string) [][2]string {
+	if val := c.lookup(name); val != nil {
+		if g, ok := val.Value.(flag.Getter); ok {
+			if p, ok := g.Get().([][2]string); ok {
+				return p
+			}
+		}
+	}
+	return nil
 }
 
 // func (c *Context) GetString(name string) string {
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGlobalReadsRunValue(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(String("region", "r").Default("us"))
+
+	var global, get string
+	mustCommand(t, app, "deploy", func(c *Context) error {
+		global, get = c.GlobalString("region"), c.GetString("region")
+		return nil
+	})
+
+	for _, args := range [][]string{
+		{"deploy", "--region", "eu"},
+		{"--region", "eu", "deploy"},
+		{"deploy", "-r", "eu"},
+	} {
+		if err := app.Parse(args); err != nil {
+			t.Fatalf("Parse(%q): %v", args, err)
+		}
+		if global != "eu" || get != "eu" {
+			t.Errorf("Parse(%q): Global = %q, GetString = %q, want eu", args, global, get)
+		}
+	}
+
+	if err := app.Parse([]string{"deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	if global != "us" {
+		t.Errorf("Global after a run without the flag = %q, want the default us", global)
+	}
+}
+
+func TestExecInherit(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(
+		Bool("verbose", "v"),
+		EnumSlice("features", []string{"a", "b", "c"}),
+		HeaderFlag("header", "H"),
+		String("region").Default("us"),
+	)
+
+	type seen struct {
+		args              []string
+		verbose           bool
+		features, headers string
+		region            string
+	}
+	var got seen
+	mustCommand(t, app, "greet", func(c *Context) error {
+		got = seen{
+			args:     c.Args(),
+			verbose:  c.GetBool("verbose"),
+			features: c.GetString("features"),
+			headers:  fmt.Sprint(c.GetPairs("header")),
+			region:   c.GetString("region"),
+		}
+		return nil
+	})
+
+	execPath, execArgs := "greet John", []string(nil)
+	mustCommand(t, app, "run", func(c *Context) error {
+		return c.ExecInherit(execPath, execArgs...)
+	})
+
+	args := []string{"run", "-v", "--features", "a,b", "--features", "c", "-H", "X-A: 1", "-H", "X-B: 2:3", "--region", "eu"}
+	if err := app.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	want := seen{[]string{"John"}, true, "a,b,c", "[[X-A 1] [X-B 2:3]]", "eu"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// a global given again to the invoked command wins
+	execPath, execArgs = "greet", []string{"--region", "ap", "John", "Doe"}
+	if err := app.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if got.region != "ap" || fmt.Sprint(got.args) != "[John Doe]" || !got.verbose {
+		t.Errorf("with explicit args got %+v", got)
+	}
+}
+
+func TestExecDoesNotInherit(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(Bool("verbose", "v"))
+
+	var verbose bool
+	mustCommand(t, app, "greet", func(c *Context) error {
+		verbose = c.GetBool("verbose")
+		return nil
+	})
+	mustCommand(t, app, "run", func(c *Context) error { return c.Exec("greet") })
+
+	if err := app.Parse([]string{"run", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	if verbose {
+		t.Error("Exec carried --verbose over")
+	}
+}
+
+func TestExecDepthLimit(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxMaxDepth(3))
+	runs := 0
+	mustCommand(t, app, "loop", func(c *Context) error {
+		runs++
+		return c.Exec("loop")
+	})
+
+	err := app.Parse([]string{"loop"})
+	if err == nil || !strings.Contains(err.Error(), "nesting depth") {
+		t.Fatalf("error = %v, want the nesting depth error", err)
+	}
+	if runs != 4 {
+		t.Errorf("loop ran %d times, want 4", runs)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var logs bytes.Buffer
+	app, _, _ := newTestApp(t, FluxDryRun(true), FluxDebug(true), FluxLogger(log.New(&logs, "", 0)))
+	var dry bool
+	mustCommand(t, app, "rm", func(c *Context) error {
+		dry = c.DryRun()
+		logs.WriteString("action ran\n")
+		return nil
+	})
+
+	for _, tt := range []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"rm"}, false},
+		{[]string{"rm", "--dry-run"}, true},
+		{[]string{"--dry-run", "rm"}, true},
+	} {
+		logs.Reset()
+		if err := app.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if dry != tt.want {
+			t.Errorf("Parse(%q): DryRun = %v, want %v", tt.args, dry, tt.want)
+		}
+		// the note comes ahead of whatever the action does
+		i := strings.Index(logs.String(), `[dry-run] running command=rm`)
+		if (i >= 0) != tt.want || i > strings.Index(logs.String(), "action ran") {
+			t.Errorf("Parse(%q): logged %q", tt.args, logs.String())
+		}
+	}
+
+	plain, _, _ := newTestApp(t)
+	mustCommand(t, plain, "rm", func(c *Context) error {
+		dry = c.DryRun()
+		return nil
+	})
+	if err := plain.Parse([]string{"rm"}); err != nil || dry {
+		t.Errorf("without FluxDryRun: DryRun = %v, %v", dry, err)
+	}
+}
+
+func TestExecTokenizesPath(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got []string
+	mustCommand(t, app, "greet", func(c *Context) error {
+		got = c.Args()
+		return nil
+	})
+	mustCommand(t, app, "main", func(c *Context) error {
+		return c.Exec(`greet "John Doe"`, "it's")
+	})
+
+	if err := app.Parse([]string{"main"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"John Doe", "it's"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestFlagNames(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(Bool("verbose", "v"))
+	var names, changed []string
+	mustCommand(t, app, "build", func(c *Context) error {
+		names, changed = c.FlagNames(), c.ChangedFlags()
+		return nil
+	}, Flags(String("output", "o").Alias("out"), Int("jobs", "j").Default(4), Bool("race")))
+
+	if err := app.Parse([]string{"build", "-o", "bin", "--race", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"help", "jobs", "output", "race", "verbose"}; !slices.Equal(names, want) {
+		t.Errorf("FlagNames = %q, want %q", names, want)
+	}
+	if want := []string{"output", "race", "verbose"}; !slices.Equal(changed, want) {
+		t.Errorf("ChangedFlags = %q, want %q", changed, want)
+	}
+
+	if err := app.Parse([]string{"build", "--out=bin"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"output"}; !slices.Equal(changed, want) {
+		t.Errorf("ChangedFlags with an alias = %q, want %q", changed, want)
+	}
+}
+
+func TestContextPath(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got string
+	record := func(c *Context) error { got = c.Path(); return nil }
+	if _, err := app.Root(record); err != nil {
+		t.Fatal(err)
+	}
+	mustCommand(t, app, "server", record)
+	mustCommand(t, app, "server start", record)
+	mustCommand(t, app, "server start now", record)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"server"}, "server"},
+		{[]string{"server", "start", "arg"}, "server start"},
+		{[]string{"server", "start", "now"}, "server start now"},
+	}
+	for _, tt := range tests {
+		got = "unset"
+		if err := app.Execute(tt.args); err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: Path() = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGetInheritedFlags(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(String("region").Default("us"))
+
+	var got []string
+	read := func(c *Context) error {
+		got = []string{c.GetString("name"), c.GetString("url"), fmt.Sprint(c.GetInt("timeout")), c.GetString("region")}
+		return nil
+	}
+	mustCommand(t, app, "remote", read, PersistentFlags(String("url").Default("origin"), Int("timeout").Default(30)))
+	mustCommand(t, app, "remote add", read, Flags(String("name")))
+	mustCommand(t, app, "remote exec", read, RawArgs())
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"remote", "add", "--name", "m"}, []string{"m", "origin", "30", "us"}},
+		{[]string{"remote", "add", "--url", "a", "--timeout", "5", "--region", "eu"}, []string{"", "a", "5", "eu"}},
+		{[]string{"remote", "exec", "--url", "a"}, []string{"", "origin", "30", "us"}},
+	}
+	for _, tt := range tests {
+		got = nil
+		if err := app.Parse(tt.args); err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: name, url, timeout, region = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotHandled is returned by a fallback, see App.SetFallback, to
+// leave an unknown command to OnNotFound.
+var ErrNotHandled = errors.New("not handled")
+
+// ErrInterrupted is returned by the prompt helpers when the user hits
+// Ctrl-C, App.In is closed while waiting, or the command's Ctx ends.
+// The command can then return it and let After hooks clean up; Run
+// exits with status 130 for it.
+var ErrInterrupted = errors.New("interrupted")
+
+// UsageError reports a misuse of the command line, such as an unknown
+// flag or a failed flag validation, as opposed to a runtime failure of
+// the command itself. The default OnError prints the command's help
+// along with it and Run exits with status 2.
+type UsageError struct {
+	Cmd *Command // command being invoked, may be nil
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitError is an error that asks Run to exit with a specific status.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Exit returns an ExitError with the formatted message.
+//
+//	return cli.Exit(3, "config %s is locked", path)
+func Exit(code int, format string, args ...any) error {
+	return &ExitError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ErrCommandNotFound reports an unknown command name. Parent is the
+// path of the deepest command that did match, "" at the top level.
+// The default OnNotFound returns it inside an ExitError with code 127.
+type ErrCommandNotFound struct {
+	Name   string
+	Parent string
+}
+
+func (e *ErrCommandNotFound) Error() string {
+	if e.Parent != "" {
+		return fmt.Sprintf("unknown subcommand %q for %q", e.Name, e.Parent)
+	}
+	return fmt.Sprintf("command %s not found", e.Name)
+}
+
+// ErrNoAction reports a command that was run, or would be, without an
+// Action.
+type ErrNoAction struct {
+	Path string
+}
+
+func (e *ErrNoAction) Error() string {
+	return "no action defined for: " + e.Path
+}
+
+// ErrDuplicateCommand reports a second registration of a command path,
+// see Override.
+type ErrDuplicateCommand struct {
+	Path string
+}
+
+func (e *ErrDuplicateCommand) Error() string {
+	return "duplicate command: " + e.Path
+}
+
+// ErrRequiredFlag reports a required flag that got no value.
+type ErrRequiredFlag struct {
+	Flag string
+}
+
+func (e *ErrRequiredFlag) Error() string {
+	return "required flag --" + e.Flag + " not provided"
+}
+
+// ErrValidation reports a flag value rejected by its checks: a range,
+// a choice list or a Validate func. Err carries the reason.
+type ErrValidation struct {
+	Flag string
+	Err  error
+}
+
+func (e *ErrValidation) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrValidation) Unwrap() error {
+	return e.Err
+}
+
+// ErrDeprecatedCommand describes a deprecated command, see Deprecated.
+// Its text is the warning printed when the command runs; with
+// FluxDeprecatedAsErrors the command fails with it instead.
+type ErrDeprecatedCommand struct {
+	Path      string
+	Since     string // version or date, may be empty
+	RemovedIn string // version or date, may be empty
+	Msg       string // e.g. "use \"get\" instead", may be empty
+}
+
+func (e *ErrDeprecatedCommand) Error() string {
+	return fmt.Sprintf("command %q is deprecated%s", e.Path, e.details())
+}
+
+// details is what follows "is deprecated", as in " since 1.2 and will
+// be removed in 2.0: use get instead".
+func (e *ErrDeprecatedCommand) details() string {
+	var s string
+	if e.Since != "" {
+		s += " since " + e.Since
+	}
+	if e.RemovedIn != "" {
+		s += " and will be removed in " + e.RemovedIn
+	}
+	if e.Msg != "" {
+		s += ": " + e.Msg
+	}
+	return s
+}
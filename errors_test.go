@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorTypes(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(*Context) error { return nil }, Flags(
+		String("host").Required(),
+		Int("port").Range(1, 10),
+	))
+	mustCommand(t, app, "stub", nil)
+
+	t.Run("not found", func(t *testing.T) {
+		err := app.Parse([]string{"bogus"})
+		var e *ErrCommandNotFound
+		if !errors.As(err, &e) || e.Name != "bogus" || e.Error() != "command bogus not found" {
+			t.Errorf("error = %v", err)
+		}
+	})
+	t.Run("no action", func(t *testing.T) {
+		err := app.Parse([]string{"stub"})
+		var e *ErrNoAction
+		if !errors.As(err, &e) || e.Path != "stub" || e.Error() != "no action defined for: stub" {
+			t.Errorf("error = %v", err)
+		}
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := app.Command("stub", nil)
+		var e *ErrDuplicateCommand
+		if !errors.As(err, &e) || e.Path != "stub" || e.Error() != "duplicate command: stub" {
+			t.Errorf("error = %v", err)
+		}
+	})
+	t.Run("required", func(t *testing.T) {
+		err := app.Parse([]string{"server", "start"})
+		var e *ErrRequiredFlag
+		if !errors.As(err, &e) || e.Flag != "host" || e.Error() != "required flag --host not provided" {
+			t.Errorf("error = %v", err)
+		}
+	})
+	t.Run("validation", func(t *testing.T) {
+		err := app.Parse([]string{"server", "start", "--host", "h", "--port", "11"})
+		var e *ErrValidation
+		if !errors.As(err, &e) || e.Flag != "port" || e.Err == nil {
+			t.Errorf("error = %v", err)
+		}
+		var ue *UsageError
+		if !errors.As(err, &ue) || ue.Cmd == nil || ue.Cmd.path != "server start" {
+			t.Errorf("error = %v, want a UsageError for server start", err)
+		}
+	})
+}
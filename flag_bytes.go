@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byte size suffixes; decimal units are powers of 1000, the "i" ones
+// powers of 1024
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseBytes parses human readable sizes like "512", "10MB", "1.5GiB".
+// Suffixes are case-insensitive; KB/MB/GB... are decimal while
+// KiB/MiB/GiB... are binary.
+func parseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	mult, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size suffix %q", s[i:])
+	}
+
+	// plain byte counts keep full int64 precision
+	if mult == 1 && !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("size %q is too large", s)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q", s)
+		}
+		return n, nil
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	v := n * mult
+	if v != math.Trunc(v) {
+		return 0, fmt.Errorf("size %q is not a whole number of bytes", s)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which no int64 holds
+	if v >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(v), nil
+}
+
+// --- bytes ---
+type bytesFlag struct {
+	flagMeta
+	def, val       int64
+	min, max       int64
+	hasMin, hasMax bool
+}
+
+// Bytes defines a size flag accepting values like "10MB" or "1.5GiB",
+// stored as a byte count. See Context.GetBytes.
+func Bytes(name string, short ...string) *bytesFlag {
+	return &bytesFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+func (f *bytesFlag) Default(v int64) *bytesFlag {
+	f.def, f.val = v, v
+	return f
+}
+
+func (f *bytesFlag) Min(v int64) *bytesFlag {
+	f.min, f.hasMin = v, true
+	return f
+}
+
+func (f *bytesFlag) Max(v int64) *bytesFlag {
+	f.max, f.hasMax = v, true
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *bytesFlag) Alias(names ...string) *bytesFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *bytesFlag) Env(names ...string) *bytesFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *bytesFlag) Renamed(oldName string) *bytesFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *bytesFlag) DefaultFunc(fn DefaultFunc) *bytesFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *bytesFlag) Help(h string) *bytesFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *bytesFlag) Group(name string) *bytesFlag {
+	f.group = name
+	return f
+}
+
+func (f *bytesFlag) Required() *bytesFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value (the byte count)
+// after parsing, when the flag was given. Validators run in order and
+// the first failure is reported.
+func (f *bytesFlag) Validate(fn func(string) error) *bytesFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *bytesFlag) String() string {
+	return strconv.FormatInt(f.val, 10)
+}
+
+func (f *bytesFlag) Set(s string) error {
+	v, err := parseBytes(s)
+	if err != nil {
+		return err
+	}
+	f.val, f.source = v, SourceCLI
+	return nil
+}
+
+func (f *bytesFlag) validate() error {
+	if f.hasMin && f.val < f.min {
+		return fmt.Errorf("flag --%s value %d below minimum %d", f.name, f.val, f.min)
+	}
+	if f.hasMax && f.val > f.max {
+		return fmt.Errorf("flag --%s value %d above maximum %d", f.name, f.val, f.max)
+	}
+	return f.check(f.String())
+}
+
+func (f *bytesFlag) typeName() string {
+	return "size"
+}
+
+func (f *bytesFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *bytesFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *bytesFlag) GetDefaultValue() string {
+	return strconv.FormatInt(f.def, 10)
+}
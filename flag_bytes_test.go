@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"10MB", 10e6, false},
+		{"1.5KiB", 1536, false},
+		{"9223372036854775807", math.MaxInt64, false},
+		{"8191PiB", 8191 << 50, false},
+		{"8192PiB", 0, true}, // exactly 2^63
+		{"9223372036854775808", 0, true},
+		{"9223372036854775807.0", 0, true}, // rounds to 2^63
+		{"0.5b", 0, true},
+		{"12XB", 0, true},
+		{"MB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBytes(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBytes(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseBytes(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// --- count ---
+type countFlag struct {
+	flagMeta
+	def, val int
+}
+
+// Count defines a flag that counts how often it is given, like -v for
+// verbosity: "-v -v" and "-vv" both give 2. "--name=3" sets the count
+// directly. See Context.GetCount.
+func Count(name string, short ...string) *countFlag {
+	return &countFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+func (f *countFlag) Default(v int) *countFlag {
+	f.def, f.val = v, v
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *countFlag) Alias(names ...string) *countFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *countFlag) Env(names ...string) *countFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *countFlag) Renamed(oldName string) *countFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *countFlag) DefaultFunc(fn DefaultFunc) *countFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *countFlag) Help(h string) *countFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *countFlag) Group(name string) *countFlag {
+	f.group = name
+	return f
+}
+
+func (f *countFlag) Required() *countFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value (the count) after
+// parsing, when the flag was given. Validators run in order and the
+// first failure is reported.
+func (f *countFlag) Validate(fn func(string) error) *countFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *countFlag) String() string {
+	return strconv.Itoa(f.val)
+}
+
+func (f *countFlag) Get() any {
+	return f.val
+}
+
+func (f *countFlag) IsBoolFlag() bool {
+	return true
+}
+
+// Set adds one for each bare occurrence ("true" from the flag
+// package); an explicit number replaces the count.
+func (f *countFlag) Set(s string) error {
+	// the first occurrence replaces the default
+	if f.source != SourceCLI {
+		f.val = 0
+	}
+	f.source = SourceCLI
+
+	if s == "true" {
+		f.val++
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return errors.New("parse error")
+	}
+	f.val = n
+	return nil
+}
+
+func (f *countFlag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *countFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *countFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *countFlag) GetDefaultValue() string {
+	return strconv.Itoa(f.def)
+}
+
+// expandCounts rewrites stacked short count flags such as -vvv to
+// -v -v -v, which the flag package would take for a flag named "vvv".
+// Like fs.Parse it stops at the first non-flag argument or "--".
+func expandCounts(fs *flag.FlagSet, args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		if !strings.HasPrefix(tok, "-") || tok == "-" || tok == "--" {
+			return append(out, args[i:]...)
+		}
+
+		name, _, hasValue := strings.Cut(tok[1:], "=")
+		if f := stackedCount(fs, name); f != nil && !hasValue {
+			for range name {
+				out = append(out, "-"+f.Name)
+			}
+			continue
+		}
+		out = append(out, tok)
+
+		// skip the value of a non-bool flag given as a separate token
+		if f := fs.Lookup(strings.TrimLeft(name, "-")); f != nil && !hasValue {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				if i+1 < len(args) {
+					out = append(out, args[i+1])
+					i++
+				}
+			}
+		}
+	}
+	return out
+}
+
+// stackedCount returns the one-letter count flag name repeats, as in
+// "vvv", nil if name is anything else.
+func stackedCount(fs *flag.FlagSet, name string) *flag.Flag {
+	if len(name) < 2 || fs.Lookup(name) != nil || strings.Count(name, name[:1]) != len(name) {
+		return nil
+	}
+	f := fs.Lookup(name[:1])
+	if f == nil {
+		return nil
+	}
+	if _, ok := f.Value.(*countFlag); !ok {
+		return nil
+	}
+	return f
+}
+
+// GetCount returns the value of a Count flag.
+func (c *Context) GetCount(name string) int {
+	return c.GetInt(name)
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// --- enum slice ---
+type enumSliceFlag struct {
+	flagMeta
+	def, val []string
+	choices  []string
+	allowAll bool
+}
+
+// EnumSlice defines a list flag whose elements must each be one of
+// choices. Values are comma separated and the flag may be repeated:
+// "--features a,b --features c" gives [a b c]. Duplicates are dropped.
+// Giving the flag replaces the default rather than adding to it. See
+// Context.GetStringSlice.
+func EnumSlice(name string, choices []string, short ...string) *enumSliceFlag {
+	return &enumSliceFlag{flagMeta: flagMeta{name: name, short: short}, choices: choices}
+}
+
+func (f *enumSliceFlag) Default(v ...string) *enumSliceFlag {
+	f.def, f.val = v, slices.Clone(v)
+	return f
+}
+
+// AllowAll makes the value "all" stand for every choice.
+func (f *enumSliceFlag) AllowAll() *enumSliceFlag {
+	f.allowAll = true
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *enumSliceFlag) Alias(names ...string) *enumSliceFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *enumSliceFlag) Env(names ...string) *enumSliceFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *enumSliceFlag) Renamed(oldName string) *enumSliceFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *enumSliceFlag) DefaultFunc(fn DefaultFunc) *enumSliceFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *enumSliceFlag) Help(h string) *enumSliceFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *enumSliceFlag) Group(name string) *enumSliceFlag {
+	f.group = name
+	return f
+}
+
+func (f *enumSliceFlag) Required() *enumSliceFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value (the elements joined
+// with commas) after parsing, when the flag was given. Validators run
+// in order and the first failure is reported.
+func (f *enumSliceFlag) Validate(fn func(string) error) *enumSliceFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *enumSliceFlag) String() string {
+	return strings.Join(f.val, ",")
+}
+
+func (f *enumSliceFlag) Get() any {
+	return slices.Clone(f.val)
+}
+
+func (f *enumSliceFlag) Set(s string) error {
+	var add []string
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		switch {
+		case e == "":
+			continue
+		case e == "all" && f.allowAll:
+			add = append(add, f.choices...)
+		case slices.Contains(f.choices, e):
+			add = append(add, e)
+		default:
+			return fmt.Errorf("%q is not one of %s", e, strings.Join(f.choices, ", "))
+		}
+	}
+
+	// the first value given replaces the default, later ones add to it
+	if f.source != SourceCLI {
+		f.val = nil
+	}
+	for _, e := range add {
+		if !slices.Contains(f.val, e) {
+			f.val = append(f.val, e)
+		}
+	}
+	f.source = SourceCLI
+	return nil
+}
+
+func (f *enumSliceFlag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *enumSliceFlag) typeName() string {
+	return "list"
+}
+
+func (f *enumSliceFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *enumSliceFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *enumSliceFlag) GetDefaultValue() string {
+	return strings.Join(f.def, ",")
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnumSlice(t *testing.T) {
+	choices := []string{"auth", "billing", "search"}
+	tests := []struct {
+		args []string
+		want []string
+		err  string
+	}{
+		{nil, []string{"auth"}, ""},
+		{[]string{"--features", "billing,search"}, []string{"billing", "search"}, ""},
+		{[]string{"--features", "search", "-f", "auth"}, []string{"search", "auth"}, ""},
+		{[]string{"--features", "auth, auth,billing", "-f", "billing"}, []string{"auth", "billing"}, ""},
+		{[]string{"--features", "all"}, []string{"auth", "billing", "search"}, ""},
+		{[]string{"--features", "billing,all"}, []string{"billing", "auth", "search"}, ""},
+		{[]string{"--features", "auth,bogus"}, nil, `"bogus" is not one of auth, billing, search`},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		var got []string
+		mustCommand(t, app, "run", func(c *Context) error {
+			got = c.GetStringSlice("features")
+			return nil
+		}, Flags(EnumSlice("features", choices, "f").Default("auth").AllowAll()))
+
+		err := app.Parse(append([]string{"run"}, tt.args...))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestEnumSliceWithoutAll(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "run", func(*Context) error { return nil },
+		Flags(EnumSlice("features", []string{"auth", "billing"})))
+
+	if err := app.Parse([]string{"run", "--features", "all"}); err == nil || !strings.Contains(err.Error(), `"all" is not one of`) {
+		t.Errorf("all without AllowAll: error = %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// --- header ---
+type headerFlag struct {
+	flagMeta
+	def, val [][2]string
+}
+
+// HeaderFlag defines a repeatable "key:value" flag such as an HTTP
+// header: "-H 'Accept: text/plain' -H 'X-Id: 1'". Each value is split
+// on its first colon, so values may contain colons, and blanks around
+// key and value are trimmed. Pairs keep the order they were given in,
+// duplicate keys included. Giving the flag replaces the default rather
+// than adding to it. See Context.GetPairs.
+func HeaderFlag(name string, short ...string) *headerFlag {
+	return &headerFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+func (f *headerFlag) Default(pairs ...[2]string) *headerFlag {
+	f.def, f.val = pairs, slices.Clone(pairs)
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *headerFlag) Alias(names ...string) *headerFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *headerFlag) Env(names ...string) *headerFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *headerFlag) Renamed(oldName string) *headerFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *headerFlag) DefaultFunc(fn DefaultFunc) *headerFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *headerFlag) Help(h string) *headerFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *headerFlag) Group(name string) *headerFlag {
+	f.group = name
+	return f
+}
+
+func (f *headerFlag) Required() *headerFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value (the pairs as
+// "key:value" joined with ", ") after parsing, when the flag was
+// given. Validators run in order and the first failure is reported.
+func (f *headerFlag) Validate(fn func(string) error) *headerFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *headerFlag) String() string {
+	return formatPairs(f.val)
+}
+
+func (f *headerFlag) Get() any {
+	return slices.Clone(f.val)
+}
+
+func (f *headerFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, ":")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return fmt.Errorf("%q is not in key:value form", s)
+	}
+
+	// the first value given replaces the default, later ones add to it
+	if f.source != SourceCLI {
+		f.val = nil
+	}
+	f.val = append(f.val, [2]string{k, strings.TrimSpace(v)})
+	f.source = SourceCLI
+	return nil
+}
+
+func (f *headerFlag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *headerFlag) typeName() string {
+	return "key:value"
+}
+
+func (f *headerFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *headerFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *headerFlag) GetDefaultValue() string {
+	return formatPairs(f.def)
+}
+
+// formatPairs renders pairs as "k1:v1, k2:v2".
+func formatPairs(pairs [][2]string) string {
+	s := make([]string, len(pairs))
+	for i, p := range pairs {
+		s[i] = p[0] + ":" + p[1]
+	}
+	return strings.Join(s, ", ")
+}
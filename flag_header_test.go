@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHeaderFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    [][2]string
+		wantErr string
+	}{
+		{nil, [][2]string{{"Accept", "*/*"}}, ""},
+		{
+			[]string{"-H", "Accept: text/plain", "-H", "X-Id: 1", "-H", "X-Id:2"},
+			[][2]string{{"Accept", "text/plain"}, {"X-Id", "1"}, {"X-Id", "2"}},
+			"",
+		},
+		{
+			[]string{"-H", "Location: https://example.com:8080/a", "-H", "Empty:"},
+			[][2]string{{"Location", "https://example.com:8080/a"}, {"Empty", ""}},
+			"",
+		},
+		{[]string{"-H", "no-colon"}, nil, `"no-colon" is not in key:value form`},
+		{[]string{"-H", " : value"}, nil, "is not in key:value form"},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		var got [][2]string
+		mustCommand(t, app, "fetch", func(c *Context) error {
+			got = c.GetPairs("header")
+			return nil
+		}, Flags(HeaderFlag("header", "H").Default([2]string{"Accept", "*/*"})))
+
+		err := app.Parse(append([]string{"fetch"}, tt.args...))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
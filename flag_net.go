@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"net"
+)
+
+// --- ip ---
+type ipFlag struct {
+	flagMeta
+	def string
+	val net.IP
+}
+
+// IP defines a flag holding an IPv4 or IPv6 address, rejected at parse
+// time when malformed. See Context.GetIP.
+func IP(name string, short ...string) *ipFlag {
+	return &ipFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// Default sets the default address in its string form, e.g. "127.0.0.1".
+func (f *ipFlag) Default(v string) *ipFlag {
+	f.def, f.val = v, net.ParseIP(v)
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *ipFlag) Alias(names ...string) *ipFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *ipFlag) Env(names ...string) *ipFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *ipFlag) Renamed(oldName string) *ipFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *ipFlag) DefaultFunc(fn DefaultFunc) *ipFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *ipFlag) Help(h string) *ipFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *ipFlag) Group(name string) *ipFlag {
+	f.group = name
+	return f
+}
+
+func (f *ipFlag) Required() *ipFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *ipFlag) Validate(fn func(string) error) *ipFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *ipFlag) String() string {
+	if f.val == nil {
+		return ""
+	}
+	return f.val.String()
+}
+
+func (f *ipFlag) Set(s string) error {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %q", s)
+	}
+	f.val, f.source = ip, SourceCLI
+	return nil
+}
+
+func (f *ipFlag) validate() error {
+	if f.def != "" && f.source == SourceDefault && f.val == nil {
+		return fmt.Errorf("flag --%s: invalid default IP address %q", f.name, f.def)
+	}
+	return f.check(f.String())
+}
+
+func (f *ipFlag) typeName() string {
+	return "ip"
+}
+
+func (f *ipFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *ipFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *ipFlag) GetDefaultValue() string {
+	return f.def
+}
+
+// --- cidr ---
+type cidrFlag struct {
+	flagMeta
+	def string
+	val *net.IPNet
+}
+
+// CIDR defines a flag holding a network in CIDR notation such as
+// "10.0.0.0/8", rejected at parse time when malformed. See
+// Context.GetCIDR.
+func CIDR(name string, short ...string) *cidrFlag {
+	return &cidrFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// Default sets the default network in its string form, e.g. "10.0.0.0/8".
+func (f *cidrFlag) Default(v string) *cidrFlag {
+	f.def = v
+	_, f.val, _ = net.ParseCIDR(v)
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *cidrFlag) Alias(names ...string) *cidrFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *cidrFlag) Env(names ...string) *cidrFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *cidrFlag) Renamed(oldName string) *cidrFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *cidrFlag) DefaultFunc(fn DefaultFunc) *cidrFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *cidrFlag) Help(h string) *cidrFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *cidrFlag) Group(name string) *cidrFlag {
+	f.group = name
+	return f
+}
+
+func (f *cidrFlag) Required() *cidrFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *cidrFlag) Validate(fn func(string) error) *cidrFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *cidrFlag) String() string {
+	if f.val == nil {
+		return ""
+	}
+	return f.val.String()
+}
+
+func (f *cidrFlag) Set(s string) error {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR %q", s)
+	}
+	f.val, f.source = n, SourceCLI
+	return nil
+}
+
+func (f *cidrFlag) validate() error {
+	if f.def != "" && f.source == SourceDefault && f.val == nil {
+		return fmt.Errorf("flag --%s: invalid default CIDR %q", f.name, f.def)
+	}
+	return f.check(f.String())
+}
+
+func (f *cidrFlag) typeName() string {
+	return "cidr"
+}
+
+func (f *cidrFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *cidrFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *cidrFlag) GetDefaultValue() string {
+	return f.def
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- path ---
+type pathFlag struct {
+	flagMeta
+	def, val  string
+	mustExist bool
+	mustDir   bool
+	mustFile  bool
+	expand    bool
+}
+
+// Path defines a file system path flag with optional existence and
+// type checks, run after parsing. See Context.GetPath.
+func Path(name string, short ...string) *pathFlag {
+	return &pathFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+func (f *pathFlag) Default(v string) *pathFlag {
+	f.def, f.val = v, v
+	return f
+}
+
+// MustExist requires the path to exist.
+func (f *pathFlag) MustExist() *pathFlag {
+	f.mustExist = true
+	return f
+}
+
+// MustBeDir requires the path to be an existing directory.
+func (f *pathFlag) MustBeDir() *pathFlag {
+	f.mustDir = true
+	return f
+}
+
+// MustBeFile requires the path to be an existing regular file.
+func (f *pathFlag) MustBeFile() *pathFlag {
+	f.mustFile = true
+	return f
+}
+
+// Expand replaces a leading "~" with the user's home directory.
+func (f *pathFlag) Expand() *pathFlag {
+	f.expand = true
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *pathFlag) Alias(names ...string) *pathFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *pathFlag) Env(names ...string) *pathFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *pathFlag) Renamed(oldName string) *pathFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *pathFlag) DefaultFunc(fn DefaultFunc) *pathFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *pathFlag) Help(h string) *pathFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *pathFlag) Group(name string) *pathFlag {
+	f.group = name
+	return f
+}
+
+func (f *pathFlag) Required() *pathFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *pathFlag) Validate(fn func(string) error) *pathFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *pathFlag) String() string {
+	return f.path()
+}
+
+func (f *pathFlag) Set(s string) error {
+	f.val, f.source = s, SourceCLI
+	return nil
+}
+
+// path returns the value, with "~" expanded when asked to.
+func (f *pathFlag) path() string {
+	if !f.expand || (f.val != "~" && !strings.HasPrefix(f.val, "~/")) {
+		return f.val
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return f.val
+	}
+	return filepath.Join(home, f.val[1:])
+}
+
+func (f *pathFlag) validate() error {
+	p := f.path()
+	if p == "" {
+		return nil
+	}
+
+	if f.mustExist || f.mustDir || f.mustFile {
+		fi, err := os.Stat(p)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("--%s: file does not exist: %s", f.name, p)
+		case err != nil:
+			return fmt.Errorf("--%s: %w", f.name, err)
+		case f.mustDir && !fi.IsDir():
+			return fmt.Errorf("--%s: not a directory: %s", f.name, p)
+		case f.mustFile && !fi.Mode().IsRegular():
+			return fmt.Errorf("--%s: not a regular file: %s", f.name, p)
+		}
+	}
+	return f.check(p)
+}
+
+func (f *pathFlag) typeName() string {
+	return "path"
+}
+
+func (f *pathFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *pathFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *pathFlag) GetDefaultValue() string {
+	return f.def
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// --- time ---
+type timeFlag struct {
+	flagMeta
+	def, val time.Time
+	layouts  []string
+}
+
+// Time defines a timestamp flag parsed with time.Parse using layout,
+// or time.RFC3339 when layout is empty. More layouts can be accepted
+// with Layouts. As with time.Parse, a value whose layout has no zone
+// is taken as UTC. See Context.GetTime.
+func Time(name, layout string, short ...string) *timeFlag {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return &timeFlag{
+		flagMeta: flagMeta{name: name, short: short},
+		layouts:  []string{layout},
+	}
+}
+
+// Layouts adds further accepted layouts, tried in order after the
+// one given to Time. Values are printed using the first layout.
+func (f *timeFlag) Layouts(l ...string) *timeFlag {
+	f.layouts = append(f.layouts, l...)
+	return f
+}
+
+func (f *timeFlag) Default(v time.Time) *timeFlag {
+	f.def, f.val = v, v
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *timeFlag) Alias(names ...string) *timeFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *timeFlag) Env(names ...string) *timeFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *timeFlag) Renamed(oldName string) *timeFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *timeFlag) DefaultFunc(fn DefaultFunc) *timeFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *timeFlag) Help(h string) *timeFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *timeFlag) Group(name string) *timeFlag {
+	f.group = name
+	return f
+}
+
+func (f *timeFlag) Required() *timeFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *timeFlag) Validate(fn func(string) error) *timeFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *timeFlag) String() string {
+	return formatTime(f.val, f.layouts)
+}
+
+func (f *timeFlag) Get() any {
+	return f.val
+}
+
+func (f *timeFlag) Set(s string) error {
+	for _, l := range f.layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			f.val, f.source = t, SourceCLI
+			return nil
+		}
+	}
+	return fmt.Errorf("time %q does not match layout %s", s, strings.Join(f.layouts, " or "))
+}
+
+func (f *timeFlag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *timeFlag) typeName() string {
+	return "time"
+}
+
+func (f *timeFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *timeFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *timeFlag) GetDefaultValue() string {
+	return formatTime(f.def, f.layouts)
+}
+
+// formatTime formats t with the first layout, "" for the zero time.
+func formatTime(t time.Time, layouts []string) string {
+	if t.IsZero() || len(layouts) == 0 {
+		return ""
+	}
+	return t.Format(layouts[0])
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeFlag(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    time.Time
+		wantErr string
+	}{
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), ""},
+		{"2024-01-02 15:04", time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC), ""},
+		{"2024-01-02T15:04:05+02:00", time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC), ""},
+		{"02/01/2024", time.Time{}, `does not match layout 2006-01-02 or 2006-01-02 15:04 or 2006-01-02T15:04:05Z07:00`},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		var got time.Time
+		mustCommand(t, app, "report", func(c *Context) error {
+			got = c.GetTime("since")
+			return nil
+		}, Flags(Time("since", time.DateOnly).Layouts("2006-01-02 15:04", time.RFC3339)))
+
+		err := app.Parse([]string{"report", "--since", tt.arg})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("--since %q: error = %v, want %q", tt.arg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("--since %q = %v, %v, want %v", tt.arg, got, err, tt.want)
+		}
+	}
+}
+
+func TestTimeFlagUnset(t *testing.T) {
+	def := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	app, _, _ := newTestApp(t)
+	var since, until time.Time
+	mustCommand(t, app, "report", func(c *Context) error {
+		since, until = c.GetTime("since"), c.GetTime("until")
+		return nil
+	}, Flags(Time("since", "").Default(def), Time("until", "")))
+
+	if err := app.Parse([]string{"report"}); err != nil {
+		t.Fatal(err)
+	}
+	if !since.Equal(def) || !until.IsZero() {
+		t.Errorf("since = %v, until = %v, want the default and the zero time", since, until)
+	}
+}
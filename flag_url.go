@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// --- url ---
+type urlFlag struct {
+	flagMeta
+	def     string
+	val     *url.URL
+	schemes []string
+}
+
+// URLFlag defines a flag holding a URL parsed with net/url. Without
+// Schemes any URL is accepted, relative ones included. See
+// Context.GetURL.
+func URLFlag(name string, short ...string) *urlFlag {
+	return &urlFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// Default sets the default URL in its string form.
+func (f *urlFlag) Default(v string) *urlFlag {
+	f.def = v
+	f.val, _ = url.Parse(v)
+	return f
+}
+
+// Schemes restricts accepted URLs to the given schemes, compared
+// case-insensitively. Restricted URLs must also be absolute with a host.
+func (f *urlFlag) Schemes(s ...string) *urlFlag {
+	f.schemes = append(f.schemes, s...)
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *urlFlag) Alias(names ...string) *urlFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *urlFlag) Env(names ...string) *urlFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *urlFlag) Renamed(oldName string) *urlFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *urlFlag) DefaultFunc(fn DefaultFunc) *urlFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *urlFlag) Help(h string) *urlFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *urlFlag) Group(name string) *urlFlag {
+	f.group = name
+	return f
+}
+
+func (f *urlFlag) Required() *urlFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *urlFlag) Validate(fn func(string) error) *urlFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *urlFlag) String() string {
+	if f.val == nil {
+		return ""
+	}
+	return f.val.String()
+}
+
+func (f *urlFlag) Set(s string) error {
+	u, err := f.parse(s)
+	if err != nil {
+		return err
+	}
+	f.val, f.source = u, SourceCLI
+	return nil
+}
+
+// parse parses s and applies the scheme restriction.
+func (f *urlFlag) parse(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q", s)
+	}
+	if len(f.schemes) == 0 {
+		return u, nil
+	}
+
+	ok := false
+	for _, sc := range f.schemes {
+		ok = ok || strings.EqualFold(u.Scheme, sc)
+	}
+	if !ok {
+		return nil, fmt.Errorf("URL %q must use scheme %s", s, strings.Join(f.schemes, " or "))
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URL %q has no host", s)
+	}
+	return u, nil
+}
+
+func (f *urlFlag) validate() error {
+	if f.def != "" && f.source == SourceDefault {
+		if _, err := f.parse(f.def); err != nil {
+			return fmt.Errorf("flag --%s: invalid default: %w", f.name, err)
+		}
+	}
+	return f.check(f.String())
+}
+
+func (f *urlFlag) typeName() string {
+	return "url"
+}
+
+func (f *urlFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *urlFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+func (f *urlFlag) GetDefaultValue() string {
+	return f.def
+}
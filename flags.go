@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Flag represents a command line flag that can be attached
@@ -12,6 +16,8 @@ type Flag interface {
 	apply(*flag.FlagSet)
 }
 
+// flagSet returns *fs, creating it if needed. Its output is pointed at
+// App.Err when the command is registered and again on every parse.
 func flagSet(fs **flag.FlagSet) *flag.FlagSet {
 	if *fs == nil {
 		*fs = flag.NewFlagSet("", flag.ContinueOnError)
@@ -19,24 +25,140 @@ func flagSet(fs **flag.FlagSet) *flag.FlagSet {
 	return *fs
 }
 
+// isFlagPassed reports whether the flag was set on the command line,
+// through its name or any short form or alias.
 func isFlagPassed(fs *flag.FlagSet, name string) bool {
-	return fs.Lookup(name).DefValue != fs.Lookup(name).Value.String()
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+
+	if c, ok := f.Value.(interface{ isChanged() bool }); ok {
+		return c.isChanged()
+	}
+
+	passed := false
+	fs.Visit(func(v *flag.Flag) {
+		passed = passed || v.Name == name
+	})
+	return passed
 }
 
-// --- string ---
-type stringFlag struct {
+// longName returns the name f was declared with, for short names,
+// aliases and former names of a typed flag as well.
+func longName(f *flag.Flag) string {
+	v := f.Value
+	if r, ok := v.(renamedFlag); ok {
+		return r.name
+	}
+	if mf, ok := v.(interface{ meta() *flagMeta }); ok {
+		return mf.meta().name
+	}
+	return f.Name
+}
+
+// flagMeta is what every flag builder has in common. It provides the
+// shared part of FlagInfo.
+type flagMeta struct {
 	name, usage string
-	def         string
 	short       []string
+	aliases     []string
+	group       string // help section, see Group
 	required    bool
+	env         []string // environment variables, see Env
+	renamed     []string // former names, see Renamed
+	source      Source   // where the current value came from
+	validators  []func(string) error
+	defFunc     DefaultFunc // computed default, see DefaultFunc
+}
+
+// register adds v to fs under all of the flag's names.
+func (m *flagMeta) register(fs *flag.FlagSet, v flag.Value) {
+	applyVar(fs, v, m.name, m.usage, m.short, m.aliases)
+	if fs.Lookup(m.name).Value != v {
+		return // lost to an existing flag
+	}
+	for _, old := range m.renamed {
+		if fs.Lookup(old) == nil {
+			fs.Var(renamedFlag{Value: v, old: old, name: m.name}, old, "deprecated, use --"+m.name)
+		}
+	}
+}
+
+func (m *flagMeta) renamedNames() []string {
+	return m.renamed
+}
+
+// check runs the user validators on value.
+func (m *flagMeta) check(value string) error {
+	return runValidators(m.name, value, m.source != SourceDefault, m.validators)
+}
+
+// isChanged reports whether the flag was set on the command line.
+func (m *flagMeta) isChanged() bool {
+	return m.source == SourceCLI
+}
+
+func (m *flagMeta) meta() *flagMeta {
+	return m
+}
+
+// --- string ---
+type stringFlag struct {
+	flagMeta
+	def, val string
+	dest     *string // mirrors val when bound to a variable
 }
 
 func String(name string, short ...string) *stringFlag {
-	return &stringFlag{name: name, short: short}
+	return &stringFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// StringVar is String bound to p: parsing stores the value in *p, like
+// flag.StringVar. The current value of *p is the default.
+func StringVar(p *string, name string, short ...string) *stringFlag {
+	f := String(name, short...)
+	f.dest = p
+	return f.Default(*p)
 }
 
 func (f *stringFlag) Default(v string) *stringFlag {
 	f.def = v
+	f.store(v)
+	return f
+}
+
+func (f *stringFlag) store(v string) {
+	f.val = v
+	if f.dest != nil {
+		*f.dest = v
+	}
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *stringFlag) Alias(names ...string) *stringFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *stringFlag) Env(names ...string) *stringFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *stringFlag) Renamed(oldName string) *stringFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *stringFlag) DefaultFunc(fn DefaultFunc) *stringFlag {
+	f.defFunc = fn
 	return f
 }
 
@@ -45,39 +167,118 @@ func (f *stringFlag) Help(h string) *stringFlag {
 	return f
 }
 
+// Group places the flag under its own heading in help output.
+func (f *stringFlag) Group(name string) *stringFlag {
+	f.group = name
+	return f
+}
+
 func (f *stringFlag) Required() *stringFlag {
 	f.required = true
 	return f
 }
 
-func (f *stringFlag) Validate() error {
-	if f.required && f.def == "" {
-		return fmt.Errorf("flag --%s is required", f.name)
-	}
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *stringFlag) Validate(fn func(string) error) *stringFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *stringFlag) String() string {
+	return f.val
+}
+
+func (f *stringFlag) Set(s string) error {
+	f.store(s)
+	f.source = SourceCLI
 	return nil
 }
 
+func (f *stringFlag) validate() error {
+	return f.check(f.val)
+}
+
+func (f *stringFlag) typeName() string {
+	return "string"
+}
+
 func (f *stringFlag) apply(fs *flag.FlagSet) {
-	if fs.Lookup(f.name) != nil {
-		return // flag already exists
-	}
-	fs.StringVar(&f.def, f.name, f.def, f.usage)
-	for _, s := range f.short {
-		if fs.Lookup(s) == nil {
-			fs.StringVar(&f.def, s, f.def, f.usage)
-		}
-	}
+	f.register(fs, f)
+}
+
+func (f *stringFlag) clone() Flag {
+	c := *f
+	return &c
 }
 
 // --- bool ---
 type boolFlag struct {
-	name, usage   string
-	short         []string
-	def, required bool
+	flagMeta
+	def, val  bool
+	dest      *bool // mirrors val when bound to a variable
+	negatable bool
 }
 
 func Bool(name string, short ...string) *boolFlag {
-	return &boolFlag{name: name, short: short}
+	return &boolFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// BoolVar is Bool bound to p: parsing stores the value in *p, like
+// flag.BoolVar. The current value of *p is the default.
+func BoolVar(p *bool, name string, short ...string) *boolFlag {
+	f := Bool(name, short...)
+	f.dest = p
+	return f.Default(*p)
+}
+
+func (f *boolFlag) Default(v bool) *boolFlag {
+	f.def = v
+	f.store(v)
+	return f
+}
+
+func (f *boolFlag) store(v bool) {
+	f.val = v
+	if f.dest != nil {
+		*f.dest = v
+	}
+}
+
+// Negatable also registers --no-<name>, which sets the flag to false.
+// When both forms are given the last one wins, so "--color --no-color"
+// ends up false.
+func (f *boolFlag) Negatable() *boolFlag {
+	f.negatable = true
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *boolFlag) Alias(names ...string) *boolFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *boolFlag) Env(names ...string) *boolFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *boolFlag) Renamed(oldName string) *boolFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *boolFlag) DefaultFunc(fn DefaultFunc) *boolFlag {
+	f.defFunc = fn
+	return f
 }
 
 func (f *boolFlag) Help(h string) *boolFlag {
@@ -85,49 +286,176 @@ func (f *boolFlag) Help(h string) *boolFlag {
 	return f
 }
 
+// Group places the flag under its own heading in help output.
+func (f *boolFlag) Group(name string) *boolFlag {
+	f.group = name
+	return f
+}
+
 func (f *boolFlag) Required() *boolFlag {
 	f.required = true
 	return f
 }
 
-func (f *boolFlag) Validate() error {
-	if f.required && !f.def {
-		return fmt.Errorf("flag --%s is required", f.name)
+// Validate adds a check run on the flag's value ("true"/"false")
+// after parsing, when the flag was given. Validators run in order and
+// the first failure is reported.
+func (f *boolFlag) Validate(fn func(string) error) *boolFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *boolFlag) String() string {
+	return strconv.FormatBool(f.val)
+}
+
+func (f *boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f *boolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("parse error")
 	}
+	f.store(v)
+	f.source = SourceCLI
 	return nil
 }
 
+func (f *boolFlag) validate() error {
+	return f.check(f.String())
+}
+
 func (f *boolFlag) apply(fs *flag.FlagSet) {
-	if fs.Lookup(f.name) != nil {
-		return // flag already exists
+	f.register(fs, f)
+
+	neg := f.negatedName()
+	if neg != "" && fs.Lookup(f.name).Value == f && fs.Lookup(neg) == nil {
+		fs.Var(negatedBool{f}, neg, "disable --"+f.name)
 	}
-	fs.BoolVar(&f.def, f.name, f.def, f.usage)
-	for _, s := range f.short {
-		if fs.Lookup(s) == nil {
-			fs.BoolVar(&f.def, s, f.def, f.usage)
-		}
+}
+
+func (f *boolFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+// negatedName returns "no-<name>" for negatable flags, "" otherwise.
+func (f *boolFlag) negatedName() string {
+	if !f.negatable {
+		return ""
+	}
+	return "no-" + f.name
+}
+
+// negatedBool is the --no-<name> side of a negatable boolFlag.
+type negatedBool struct {
+	f *boolFlag
+}
+
+func (n negatedBool) String() string {
+	if n.f == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!n.f.val)
+}
+
+func (n negatedBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New("parse error")
 	}
+	n.f.store(!v)
+	n.f.source = SourceCLI
+	return nil
+}
+
+func (n negatedBool) IsBoolFlag() bool {
+	return true
+}
+
+// renamedFlag is a former name of a flag, see Renamed. It shares the
+// flag's value; parseFlags warns when it is used.
+type renamedFlag struct {
+	flag.Value
+	old, name string
+}
+
+func (r renamedFlag) IsBoolFlag() bool {
+	b, ok := r.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (r renamedFlag) isChanged() bool {
+	c, ok := r.Value.(interface{ isChanged() bool })
+	return ok && c.isChanged()
 }
 
 // --- int ---
 type intFlag struct {
-	name, usage string
-	short       []string
-	def         int
-	min, max    int
+	flagMeta
+	def, val int
+	dest     *int // mirrors val when bound to a variable
+	min, max int
+	ranged   bool
+}
+
+func Int(name string, short ...string) *intFlag {
+	return &intFlag{flagMeta: flagMeta{name: name, short: short}}
 }
 
-func Int(name string) *intFlag {
-	return &intFlag{name: name}
+// IntVar is Int bound to p: parsing stores the value in *p, like
+// flag.IntVar. The current value of *p is the default.
+func IntVar(p *int, name string, short ...string) *intFlag {
+	f := Int(name, short...)
+	f.dest = p
+	return f.Default(*p)
 }
 
 func (f *intFlag) Default(v int) *intFlag {
 	f.def = v
+	f.store(v)
 	return f
 }
 
+func (f *intFlag) store(v int) {
+	f.val = v
+	if f.dest != nil {
+		*f.dest = v
+	}
+}
+
 func (f *intFlag) Range(min, max int) *intFlag {
 	f.min, f.max = min, max
+	f.ranged = true
+	return f
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *intFlag) Alias(names ...string) *intFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *intFlag) Env(names ...string) *intFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *intFlag) Renamed(oldName string) *intFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *intFlag) DefaultFunc(fn DefaultFunc) *intFlag {
+	f.defFunc = fn
 	return f
 }
 
@@ -136,25 +464,527 @@ func (f *intFlag) Help(h string) *intFlag {
 	return f
 }
 
-func (f *intFlag) Validate() error {
-	if f.def < f.min || f.def > f.max {
-		return fmt.Errorf("flag -%s value %d out of range [%d,%d]", f.name, f.def, f.min, f.max)
+// Group places the flag under its own heading in help output.
+func (f *intFlag) Group(name string) *intFlag {
+	f.group = name
+	return f
+}
+
+func (f *intFlag) Required() *intFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *intFlag) Validate(fn func(string) error) *intFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *intFlag) String() string {
+	return strconv.Itoa(f.val)
+}
+
+func (f *intFlag) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New("parse error")
 	}
+	f.store(int(v))
+	f.source = SourceCLI
 	return nil
 }
 
+func (f *intFlag) validate() error {
+	if f.ranged && (f.val < f.min || f.val > f.max) {
+		return fmt.Errorf("flag --%s value %d out of range [%d,%d]", f.name, f.val, f.min, f.max)
+	}
+	return f.check(f.String())
+}
+
+func (f *intFlag) typeName() string {
+	return "int"
+}
+
 func (f *intFlag) apply(fs *flag.FlagSet) {
-	if fs.Lookup(f.name) != nil {
-		return
+	f.register(fs, f)
+}
+
+func (f *intFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+// --- float64 ---
+type float64Flag struct {
+	flagMeta
+	def, val float64
+	dest     *float64 // mirrors val when bound to a variable
+}
+
+func Float64(name string, short ...string) *float64Flag {
+	return &float64Flag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// Float64Var is Float64 bound to p: parsing stores the value in *p, like
+// flag.Float64Var. The current value of *p is the default.
+func Float64Var(p *float64, name string, short ...string) *float64Flag {
+	f := Float64(name, short...)
+	f.dest = p
+	return f.Default(*p)
+}
+
+func (f *float64Flag) Default(v float64) *float64Flag {
+	f.def = v
+	f.store(v)
+	return f
+}
+
+func (f *float64Flag) store(v float64) {
+	f.val = v
+	if f.dest != nil {
+		*f.dest = v
 	}
-	fs.IntVar(&f.def, f.name, f.def, f.usage)
-	for _, s := range f.short {
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *float64Flag) Alias(names ...string) *float64Flag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *float64Flag) Env(names ...string) *float64Flag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *float64Flag) Renamed(oldName string) *float64Flag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *float64Flag) DefaultFunc(fn DefaultFunc) *float64Flag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *float64Flag) Help(h string) *float64Flag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *float64Flag) Group(name string) *float64Flag {
+	f.group = name
+	return f
+}
+
+func (f *float64Flag) Required() *float64Flag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *float64Flag) Validate(fn func(string) error) *float64Flag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *float64Flag) String() string {
+	return strconv.FormatFloat(f.val, 'g', -1, 64)
+}
+
+func (f *float64Flag) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return errors.New("parse error")
+	}
+	f.store(v)
+	f.source = SourceCLI
+	return nil
+}
+
+func (f *float64Flag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *float64Flag) typeName() string {
+	return "float"
+}
+
+func (f *float64Flag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *float64Flag) clone() Flag {
+	c := *f
+	return &c
+}
+
+// --- duration ---
+type durationFlag struct {
+	flagMeta
+	def, val time.Duration
+	dest     *time.Duration // mirrors val when bound to a variable
+}
+
+// Duration defines a flag parsed with time.ParseDuration, e.g. "1m30s".
+func Duration(name string, short ...string) *durationFlag {
+	return &durationFlag{flagMeta: flagMeta{name: name, short: short}}
+}
+
+// DurationVar is Duration bound to p: parsing stores the value in *p, like
+// flag.DurationVar. The current value of *p is the default.
+func DurationVar(p *time.Duration, name string, short ...string) *durationFlag {
+	f := Duration(name, short...)
+	f.dest = p
+	return f.Default(*p)
+}
+
+func (f *durationFlag) Default(v time.Duration) *durationFlag {
+	f.def = v
+	f.store(v)
+	return f
+}
+
+func (f *durationFlag) store(v time.Duration) {
+	f.val = v
+	if f.dest != nil {
+		*f.dest = v
+	}
+}
+
+// Alias adds long alternative names, e.g. "out" for "output".
+func (f *durationFlag) Alias(names ...string) *durationFlag {
+	f.aliases = append(f.aliases, names...)
+	return f
+}
+
+// Env names environment variables to read the value from when the
+// flag is not given, see App.FlagSources. The first one set wins.
+func (f *durationFlag) Env(names ...string) *durationFlag {
+	f.env = append(f.env, names...)
+	return f
+}
+
+// Renamed keeps a former name of the flag working. Using it sets this
+// flag and prints a deprecation warning to App.Err.
+func (f *durationFlag) Renamed(oldName string) *durationFlag {
+	f.renamed = append(f.renamed, oldName)
+	return f
+}
+
+// DefaultFunc computes the default from other flags when the flag is
+// not given, see the DefaultFunc type.
+func (f *durationFlag) DefaultFunc(fn DefaultFunc) *durationFlag {
+	f.defFunc = fn
+	return f
+}
+
+func (f *durationFlag) Help(h string) *durationFlag {
+	f.usage = h
+	return f
+}
+
+// Group places the flag under its own heading in help output.
+func (f *durationFlag) Group(name string) *durationFlag {
+	f.group = name
+	return f
+}
+
+func (f *durationFlag) Required() *durationFlag {
+	f.required = true
+	return f
+}
+
+// Validate adds a check run on the flag's value after parsing, when
+// the flag was given. Validators run in order and the first failure
+// is reported.
+func (f *durationFlag) Validate(fn func(string) error) *durationFlag {
+	f.validators = append(f.validators, fn)
+	return f
+}
+
+func (f *durationFlag) String() string {
+	return f.val.String()
+}
+
+func (f *durationFlag) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("parse error")
+	}
+	f.store(v)
+	f.source = SourceCLI
+	return nil
+}
+
+func (f *durationFlag) validate() error {
+	return f.check(f.String())
+}
+
+func (f *durationFlag) typeName() string {
+	return "duration"
+}
+
+func (f *durationFlag) apply(fs *flag.FlagSet) {
+	f.register(fs, f)
+}
+
+func (f *durationFlag) clone() Flag {
+	c := *f
+	return &c
+}
+
+// applyVar registers v under name, its short forms and long aliases.
+// If name already exists nothing is registered; a short form or alias
+// that collides with an existing flag is skipped, the existing flag wins.
+func applyVar(fs *flag.FlagSet, v flag.Value, name, usage string, short, aliases []string) {
+	if fs.Lookup(name) != nil {
+		return // flag already exists
+	}
+	fs.Var(v, name, usage)
+	for _, s := range short {
 		if fs.Lookup(s) == nil {
-			fs.IntVar(&f.def, s, f.def, f.usage)
+			fs.Var(v, s, usage)
+		}
+	}
+	for _, al := range aliases {
+		if fs.Lookup(al) == nil {
+			fs.Var(v, al, usage)
 		}
 	}
 }
 
+// lookupFlag is fs.Lookup that, with fold, falls back to a flag whose
+// name differs only in case.
+func lookupFlag(fs *flag.FlagSet, name string, fold bool) *flag.Flag {
+	f := fs.Lookup(name)
+	if f != nil || !fold {
+		return f
+	}
+	fs.VisitAll(func(fl *flag.Flag) {
+		if f == nil && strings.EqualFold(fl.Name, name) {
+			f = fl
+		}
+	})
+	return f
+}
+
+// splitUnknown moves flag tokens in args that name no flag of fs, see
+// AllowUnknownFlags, behind a "--" together with the other positional
+// arguments, keeping their order. Known flags are picked out anywhere
+// before a "--", along with their values.
+func splitUnknown(fs *flag.FlagSet, args []string, fold bool) []string {
+	var known, pos []string
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		if tok == "--" {
+			pos = append(pos, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(tok, "-") || tok == "-" {
+			pos = append(pos, tok)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(tok, "-"), "=")
+		f := lookupFlag(fs, name, fold)
+		if f == nil && !hasValue {
+			f = stackedCount(fs, name) // -vvv
+		}
+		if f == nil {
+			pos = append(pos, tok)
+			continue
+		}
+		known = append(known, tok)
+
+		// take along the value of a non-bool flag given as a separate token
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && (!ok || !b.IsBoolFlag()) && i+1 < len(args) {
+			known = append(known, args[i+1])
+			i++
+		}
+	}
+
+	if len(pos) == 0 {
+		return known
+	}
+	return append(append(known, "--"), pos...)
+}
+
+// foldFlags rewrites flag tokens in args whose name matches a flag of
+// fs only when case is ignored, e.g. --VERBOSE to --verbose. Values are
+// left as given. Like fs.Parse it stops at the first non-flag argument
+// or "--".
+func foldFlags(fs *flag.FlagSet, args []string) []string {
+	out := append([]string(nil), args...)
+	for i := 0; i < len(out); i++ {
+		tok := out[i]
+		if !strings.HasPrefix(tok, "-") || tok == "-" || tok == "--" {
+			break
+		}
+
+		dashes := tok[:len(tok)-len(strings.TrimLeft(tok, "-"))]
+		name, value, hasValue := strings.Cut(tok[len(dashes):], "=")
+		f := lookupFlag(fs, name, true)
+		if f != nil && f.Name != name {
+			out[i] = dashes + f.Name
+			if hasValue {
+				out[i] += "=" + value
+			}
+		}
+
+		// skip the value of a non-bool flag given as a separate token
+		if f != nil && !hasValue {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+			}
+		}
+	}
+	return out
+}
+
+// expandFlags rewrites "--" tokens in args that name no flag of fs but
+// are a prefix of the long names of exactly one flag to its name, e.g.
+// --ver to --verbose, even when an alias matches too. Like fs.Parse it
+// stops at the first non-flag argument or "--". Several candidate
+// flags are an error.
+func expandFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	out := append([]string(nil), args...)
+	for i := 0; i < len(out); i++ {
+		tok := out[i]
+		if !strings.HasPrefix(tok, "-") || tok == "-" || tok == "--" {
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(tok, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && strings.HasPrefix(tok, "--") {
+			// one candidate per flag, whatever the names it has: the
+			// current one, else an alias, else a former name
+			var matches []*flag.Flag
+			idx := make(map[any]int)
+			fs.VisitAll(func(fl *flag.Flag) {
+				if len(fl.Name) <= 1 || !strings.HasPrefix(fl.Name, name) {
+					return
+				}
+				key, _ := flagKey(fl)
+				j, ok := idx[key]
+				if !ok {
+					idx[key] = len(matches)
+					matches = append(matches, fl)
+					return
+				}
+				if flagNameRank(fl) < flagNameRank(matches[j]) {
+					matches[j] = fl
+				}
+			})
+			switch len(matches) {
+			case 0:
+				continue // left for fs.Parse to report
+			case 1:
+				f = matches[0]
+				out[i] = "--" + f.Name
+				if hasValue {
+					out[i] += "=" + value
+				}
+			default:
+				names := make([]string, len(matches))
+				for j, m := range matches {
+					names[j] = "--" + m.Name
+				}
+				return nil, fmt.Errorf("ambiguous flag --%s: could be %s", name, strings.Join(names, ", "))
+			}
+		}
+
+		// skip the value of a non-bool flag given as a separate token
+		if f != nil && !hasValue {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+			}
+		}
+	}
+	return out, nil
+}
+
+// flagKey returns what identifies the flag behind f among all its
+// names, and its value with any former-name wrapper removed.
+func flagKey(f *flag.Flag) (any, flag.Value) {
+	v := f.Value
+	if r, ok := v.(renamedFlag); ok {
+		v = r.Value
+	}
+	if mf, ok := v.(interface{ meta() *flagMeta }); ok {
+		return mf.meta(), v
+	}
+	return f.Name, v
+}
+
+// flagNameRank orders the names of one flag for abbreviations: its
+// name, then its aliases, then its former names.
+func flagNameRank(f *flag.Flag) int {
+	if _, ok := f.Value.(renamedFlag); ok {
+		return 2
+	}
+	if mf, ok := f.Value.(interface{ meta() *flagMeta }); ok && mf.meta().name == f.Name {
+		return 0
+	}
+	return 1
+}
+
+// validateFlags checks every flag of fs once, whatever the number of
+// names it was registered under: required flags must have a value from
+// a source other than their default, and the flag's own checks
+// (ranges, choices, Validate funcs) must pass. All failures are
+// reported, joined in flag name order, as ErrRequiredFlag and
+// ErrValidation errors.
+func validateFlags(fs *flag.FlagSet) error {
+	var errs []error
+	seen := make(map[any]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		key, v := flagKey(f)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		if fi, ok := v.(FlagInfo); ok && fi.IsRequired() && flagSource(fs, f.Name) == SourceDefault {
+			errs = append(errs, &ErrRequiredFlag{Flag: longName(f)})
+			return
+		}
+		if vf, ok := v.(interface{ validate() error }); ok {
+			if err := vf.validate(); err != nil {
+				errs = append(errs, &ErrValidation{Flag: longName(f), Err: err})
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// runValidators runs vs on value of a set flag and wraps the
+// first failure with the flag name.
+func runValidators(name, value string, set bool, vs []func(string) error) error {
+	if !set {
+		return nil
+	}
+	for _, fn := range vs {
+		if err := fn(value); err != nil {
+			return fmt.Errorf("invalid value %q for flag --%s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
 func (a *App) Flags(ff ...Flag) *App {
 	a.globals = append(a.globals, ff...)
 	return a
@@ -164,8 +994,131 @@ func Flags(ff ...Flag) CommandOption {
 	return func(cmd *Command) {
 		for _, f := range ff {
 			f.apply(flagSet(&cmd.Flags))
+			cmd.flags = append(cmd.flags, f)
+		}
+	}
+}
+
+// PersistentFlags declares flags on the command that its subcommands,
+// at any depth, accept too. A subcommand's own flag of the same name
+// takes precedence.
+func PersistentFlags(ff ...Flag) CommandOption {
+	return func(cmd *Command) {
+		Flags(ff...)(cmd)
+		cmd.persistent = append(cmd.persistent, ff...)
+	}
+}
+
+// checkFlagNames reports the first flag in ff whose name, short form
+// or alias is already taken by an earlier one, which would otherwise
+// silently lose that binding.
+func checkFlagNames(ff []Flag) error {
+	owner := make(map[string]string)
+
+	for _, f := range ff {
+		fi, ok := f.(FlagInfo)
+		if !ok {
+			continue
+		}
+
+		name := fi.GetName()
+		if o, ok := owner[name]; ok {
+			return fmt.Errorf("flag --%s already used by --%s", name, o)
+		}
+		owner[name] = name
+
+		for _, s := range fi.GetShort() {
+			if o, ok := owner[s]; ok {
+				return fmt.Errorf("short flag -%s already used by --%s", s, o)
+			}
+			owner[s] = name
+		}
+
+		for _, al := range fi.GetAliases() {
+			if o, ok := owner[al]; ok {
+				return fmt.Errorf("flag alias --%s already used by --%s", al, o)
+			}
+			owner[al] = name
 		}
+
+		if r, ok := f.(interface{ renamedNames() []string }); ok {
+			for _, old := range r.renamedNames() {
+				if o, ok := owner[old]; ok {
+					return fmt.Errorf("flag --%s already used by --%s", old, o)
+				}
+				owner[old] = name
+			}
+		}
+
+		if n, ok := f.(interface{ negatedName() string }); ok && n.negatedName() != "" {
+			neg := n.negatedName()
+			if o, ok := owner[neg]; ok {
+				return fmt.Errorf("flag --%s already used by --%s", neg, o)
+			}
+			owner[neg] = name
+		}
+	}
+	return nil
+}
+
+// cloneFlag returns a copy of f that parses independently of f.
+func cloneFlag(f Flag) Flag {
+	if c, ok := f.(interface{ clone() Flag }); ok {
+		return c.clone()
 	}
+	return f
+}
+
+// bindFlags returns a FlagSet for one run of c. It holds copies of c's
+// typed flags and of the inherited ones, so runs never share parsed
+// values and the declared flags keep their defaults; copies maps each
+// declared flag to its copy. Plain flags added to c.Flags directly are
+// shared by every run.
+func (a *App) bindFlags(c *Command, inherited []Flag) (fs *flag.FlagSet, copies map[Flag]Flag) {
+	fs = a.newFlagSet(c.Name)
+
+	// plain flags first: they only hold names no typed flag took
+	if c.Flags != nil {
+		c.Flags.VisitAll(func(f *flag.Flag) {
+			switch f.Value.(type) {
+			case FlagInfo, negatedBool, renamedFlag:
+				return
+			}
+			fs.Var(f.Value, f.Name, f.Usage)
+			fs.Lookup(f.Name).DefValue = f.DefValue
+		})
+	}
+
+	copies = make(map[Flag]Flag)
+	for _, f := range append(c.flags[:len(c.flags):len(c.flags)], inherited...) {
+		cp, ok := copies[f]
+		if !ok {
+			cp = cloneFlag(f)
+			copies[f] = cp
+		}
+		cp.apply(fs)
+	}
+	return fs, copies
+}
+
+// withGlobals returns the command's flags followed by the globals
+// it doesn't shadow by name.
+func (c *Command) withGlobals(globals []Flag) []Flag {
+	local := make(map[string]bool)
+	for _, f := range c.flags {
+		if fi, ok := f.(FlagInfo); ok {
+			local[fi.GetName()] = true
+		}
+	}
+
+	out := append([]Flag(nil), c.flags...)
+	for _, g := range globals {
+		if fi, ok := g.(FlagInfo); ok && local[fi.GetName()] {
+			continue
+		}
+		out = append(out, g)
+	}
+	return out
 }
 
 // FlagInfo exposes the minimal read-only view of a flag.
@@ -173,53 +1126,59 @@ type FlagInfo interface {
 	GetName() string         // long name, e.g. "config"
 	GetUsage() string        // help text
 	GetShort() []string      // short name, e.g. "c"
+	GetAliases() []string    // long aliases, e.g. "out"
+	GetGroup() string        // help section, "" if ungrouped
 	GetDefaultValue() string // default value as string
 	HasShort() bool          // true if has short form
 	IsBool() bool            // true if boolean flag
+	IsRequired() bool        // true if the flag must be given
 }
 
 // i have no idea how to do this actually, so, here you go.
 //
 // READ-ONLY HELPER
-func (f *stringFlag) GetName() string {
-	return f.name
+func (m *flagMeta) GetName() string {
+	return m.name
 }
-func (f *stringFlag) GetUsage() string {
-	return f.usage
+func (m *flagMeta) GetUsage() string {
+	return m.usage
 }
-func (f *stringFlag) GetShort() []string {
-	if len(f.short) > 0 {
-		return f.short
+func (m *flagMeta) GetShort() []string {
+	if len(m.short) > 0 {
+		return m.short
 	}
 	return nil
 }
-func (f *stringFlag) GetDefaultValue() string {
-	return f.def
+func (m *flagMeta) GetAliases() []string {
+	return m.aliases
 }
-func (f *stringFlag) IsBool() bool {
-	return false
+func (m *flagMeta) GetGroup() string {
+	return m.group
 }
-func (f *stringFlag) HasShort() bool {
-	return len(f.short) > 0
+func (m *flagMeta) HasShort() bool {
+	return len(m.short) > 0
 }
-func (f *boolFlag) GetName() string {
-	return f.name
+func (m *flagMeta) IsRequired() bool {
+	return m.required
 }
-func (f *boolFlag) GetUsage() string {
-	return f.usage
+func (m *flagMeta) IsBool() bool {
+	return false
 }
-func (f *boolFlag) GetShort() []string {
-	if len(f.short) > 0 {
-		return f.short
-	}
-	return nil
+func (f *stringFlag) GetDefaultValue() string {
+	return f.def
 }
 func (f *boolFlag) GetDefaultValue() string {
 	return fmt.Sprintf("%t", f.def)
 }
-func (f *boolFlag) HasShort() bool {
-	return len(f.short) > 0
-}
 func (f *boolFlag) IsBool() bool {
 	return true
 }
+func (f *intFlag) GetDefaultValue() string {
+	return strconv.Itoa(f.def)
+}
+func (f *float64Flag) GetDefaultValue() string {
+	return strconv.FormatFloat(f.def, 'g', -1, 64)
+}
+func (f *durationFlag) GetDefaultValue() string {
+	return f.def.String()
+}
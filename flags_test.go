@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequiredFlags(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		flag Flag
+		arg  string
+	}{
+		{"string", String("f").Default("x").Required(), "--f=y"},
+		{"bool", Bool("f").Default(true).Required(), "--f"},
+		{"int", Int("f").Default(1).Required(), "--f=2"},
+		{"float", Float64("f").Default(1.5).Required(), "--f=2"},
+		{"duration", Duration("f").Default(time.Second).Required(), "--f=2s"},
+		{"count", Count("f").Default(1).Required(), "--f"},
+		{"bytes", Bytes("f").Default(10).Required(), "--f=1KB"},
+		{"enum slice", EnumSlice("f", []string{"a", "b"}).Default("a").Required(), "--f=b"},
+		{"header", HeaderFlag("f").Default([2]string{"k", "v"}).Required(), "--f=k:w"},
+		{"ip", IP("f").Default("127.0.0.1").Required(), "--f=::1"},
+		{"cidr", CIDR("f").Default("10.0.0.0/8").Required(), "--f=10.0.0.0/16"},
+		{"path", Path("f").Default("/tmp").Required(), "--f=/"},
+		{"time", Time("f", "").Default(now).Required(), "--f=2024-01-02T03:04:05Z"},
+		{"url", URLFlag("f").Default("http://a").Required(), "--f=http://b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, _ := newTestApp(t)
+			mustCommand(t, app, "run", func(c *Context) error { return nil }, Flags(tt.flag))
+
+			// a default does not count as providing the flag
+			err := app.Parse([]string{"run"})
+			var rf *ErrRequiredFlag
+			if !errors.As(err, &rf) || rf.Flag != "f" {
+				t.Fatalf("without the flag: err = %v, want ErrRequiredFlag for --f", err)
+			}
+
+			if err := app.Parse([]string{"run", tt.arg}); err != nil {
+				t.Errorf("with %s: %v", tt.arg, err)
+			}
+		})
+	}
+}
+
+func TestRequiredFlagFromEnv(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "run", func(c *Context) error { return nil },
+		Flags(String("token").Env("TEST_REQUIRED_TOKEN").Required()))
+
+	t.Setenv("TEST_REQUIRED_TOKEN", "abc")
+	if err := app.Parse([]string{"run"}); err != nil {
+		t.Errorf("value from env: %v", err)
+	}
+}
+
+func TestValidateFlagsJoinsErrors(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "run", func(c *Context) error { return nil }, Flags(
+		String("a").Required(),
+		Int("b").Range(1, 5),
+		String("c").Validate(func(s string) error { return errors.New("bad") }),
+	))
+
+	err := app.Parse([]string{"run", "--b=9", "--c=x"})
+	var rf *ErrRequiredFlag
+	if !errors.As(err, &rf) || rf.Flag != "a" {
+		t.Errorf("err = %v, want ErrRequiredFlag for --a", err)
+	}
+	var ve *ErrValidation
+	if !errors.As(err, &ve) {
+		t.Errorf("err = %v, want an ErrValidation", err)
+	}
+	for _, name := range []string{"--b", "--c"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("err = %q, does not mention %s", err, name)
+		}
+	}
+}
+
+func TestFlagNameCollisionsAtRegistration(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(String("region", "r"))
+	mustCommand(t, app, "remote", nil, PersistentFlags(String("output", "o")))
+
+	tests := []struct {
+		path string
+		flag Flag
+		want string
+	}{
+		{"x", String("host", "h"), "short flag -h already used by --host"},
+		{"y", String("root", "r"), "short flag -r already used by --root"},
+		{"remote add", String("origin", "o"), "short flag -o already used by --origin"},
+	}
+	for _, tt := range tests {
+		_, err := app.Command(tt.path, func(*Context) error { return nil }, Flags(tt.flag))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Command(%q) error = %v, want %q", tt.path, err, tt.want)
+		}
+		if _, ok := app.lookupNode(tt.path); ok {
+			t.Errorf("Command(%q) registered despite the collision", tt.path)
+		}
+	}
+
+	// a local flag may shadow a global by its long name
+	mustCommand(t, app, "z", func(*Context) error { return nil }, Flags(String("region", "r")))
+}
+
+func TestFlagAbbreviations(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxFlagAbbreviations(true))
+	var got string
+	mustCommand(t, app, "build", func(c *Context) error {
+		got = fmt.Sprintf("%v %v %s %q", c.GetBool("verbose"), c.GetBool("verify"), c.GetString("output"), []string(c.Args()))
+		return nil
+	}, Flags(Bool("verbose"), Bool("verify"), String("output", "o"), Bool("v")))
+
+	tests := []struct {
+		args []string
+		want string
+		err  string
+	}{
+		{[]string{"--verb"}, `true false  []`, ""},
+		{[]string{"--veri", "--out", "a", "x"}, `false true a ["x"]`, ""},
+		{[]string{"--outp=b", "--", "--verb"}, `false false b ["--verb"]`, ""},
+		{[]string{"--verbose", "-v"}, `true false  []`, ""},
+		{[]string{"--ve"}, "", "ambiguous flag --ve: could be --verbose, --verify"},
+		{[]string{"-verb"}, "", "flag provided but not defined: -verb"},
+		{[]string{"--x"}, "", "flag provided but not defined: -x"},
+	}
+	for _, tt := range tests {
+		got = ""
+		err := app.Parse(append([]string{"build"}, tt.args...))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %s, %v, want %s", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestFlagAbbreviationsAliases(t *testing.T) {
+	app, _, errOut := newTestApp(t, FluxFlagAbbreviations(true))
+	var got string
+	mustCommand(t, app, "build", func(c *Context) error {
+		got = fmt.Sprintf("%v %s", c.GetBool("verbose"), c.GetString("target"))
+		return nil
+	}, Flags(Bool("verbose").Alias("verb").Renamed("verbosity"), String("target").Alias("tgt"), String("tag")))
+
+	tests := []struct {
+		args []string
+		want string
+		err  string
+	}{
+		{[]string{"--ve"}, "true ", ""},
+		{[]string{"--verbo"}, "true ", ""},
+		{[]string{"--tar", "x"}, "false x", ""},
+		{[]string{"--tg=y"}, "false y", ""},
+		{[]string{"--ta"}, "", "ambiguous flag --ta: could be --tag, --target"},
+	}
+	for _, tt := range tests {
+		got = ""
+		err := app.Parse(append([]string{"build"}, tt.args...))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %s, %v, want %s", tt.args, got, err, tt.want)
+		}
+	}
+	if strings.Contains(errOut.String(), "deprecated") {
+		t.Errorf("an abbreviation picked the former name: %s", errOut)
+	}
+}
+
+func TestFlagErrorsGoToAppErr(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	app, out, errOut := newTestApp(t, FluxFlagErrorHandling(FlagErrorsStdlib))
+	mustCommand(t, app, "greet", func(*Context) error { return nil }, Flags(String("name")))
+	if err := app.Parse([]string{"greet", "--bogus"}); err == nil {
+		t.Fatal("Parse succeeded with an unknown flag")
+	}
+
+	w.Close()
+	real, _ := io.ReadAll(r)
+	if len(real) != 0 {
+		t.Errorf("wrote %q to os.Stderr", real)
+	}
+	if !strings.Contains(errOut.String(), "flag provided but not defined: -bogus") || !strings.Contains(errOut.String(), "-name") {
+		t.Errorf("App.Err = %q, want the error and usage", errOut)
+	}
+	if out.Len() != 0 {
+		t.Errorf("App.Out = %q, want nothing", out)
+	}
+}
+
+func TestPersistentFlags(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got string
+	run := func(c *Context) error {
+		got = c.GetString("url")
+		return nil
+	}
+	mustCommand(t, app, "remote", run, PersistentFlags(String("url", "u").Default("origin")))
+	mustCommand(t, app, "remote add", nil)
+	mustCommand(t, app, "remote add mirror", run)
+	mustCommand(t, app, "status", run)
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"remote", "--url", "a"}, "a"},
+		{[]string{"remote", "add", "mirror", "-u", "b"}, "b"},
+		{[]string{"remote", "add", "mirror"}, "origin"},
+	} {
+		got = ""
+		if err := app.Parse(tt.args); err != nil || got != tt.want {
+			t.Errorf("Parse(%q): url = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	if err := app.Parse([]string{"status", "--url", "a"}); err == nil || !strings.Contains(err.Error(), "not defined: -url") {
+		t.Errorf("status --url: error = %v, want an unknown flag", err)
+	}
+}
+
+func TestValidationErrorsAggregate(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "run", func(c *Context) error { return nil }, Flags(
+		String("user").Required(),
+		String("token").Required(),
+		Int("port").Range(1, 65535),
+		Int("workers").Range(1, 8),
+	))
+
+	err := app.Parse([]string{"run", "--port", "0", "--workers", "9"})
+	if err == nil {
+		t.Fatal("Parse succeeded")
+	}
+	for _, want := range []string{
+		"required flag --user not provided",
+		"required flag --token not provided",
+		"--port",
+		"--workers",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%v", want, err)
+		}
+	}
+	if n := len(strings.Split(err.Error(), "\n")); n != 4 {
+		t.Errorf("error has %d lines, want 4:\n%v", n, err)
+	}
+}
+
+func TestOnFlagError(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var ran bool
+	var port int
+	mustCommand(t, app, "serve", func(c *Context) error {
+		ran, port = true, c.GetInt("port")
+		return nil
+	}, Flags(Int("port").Default(80), Bool("tls")))
+
+	var seen error
+	app.OnFlagError = func(c *Context, err error) error {
+		seen = err
+		if strings.Contains(err.Error(), "-bogus") {
+			return nil // drop it
+		}
+		return fmt.Errorf("serve: %w (see serve --help)", err)
+	}
+
+	err := app.Parse([]string{"serve", "--port", "x"})
+	var ue *UsageError
+	if err == nil || !strings.HasPrefix(err.Error(), "serve: invalid value") || !errors.As(err, &ue) || ue.Cmd.path != "serve" {
+		t.Errorf("rewritten error = %v", err)
+	}
+	if !errors.As(seen, &ue) || ran {
+		t.Errorf("handler saw %v, action ran %v", seen, ran)
+	}
+
+	if err := app.Parse([]string{"serve", "--port", "8080", "--bogus", "--tls"}); err != nil || !ran || port != 8080 {
+		t.Errorf("dropped error: %v, ran %v, port %d", err, ran, port)
+	}
+}
+
+func TestAllowUnknownFlags(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(String("region").Default("us"))
+
+	var got []string
+	mustCommand(t, app, "wrap", func(c *Context) error {
+		got = append([]string{fmt.Sprint(c.GetBool("dry")), c.GetString("image"), fmt.Sprint(c.GetInt("verbose")), c.GetString("region")}, c.Args()...)
+		return nil
+	}, AllowUnknownFlags(), Flags(Bool("dry"), String("image", "i"), Count("verbose", "v")))
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"wrap", "-x", "--dry", "ls", "--image", "alpine", "--color=auto", "-vv", "--region", "eu", "-", "--", "--dry"},
+			[]string{"true", "alpine", "2", "eu", "-x", "ls", "--color=auto", "-", "--dry"},
+		},
+		{[]string{"wrap", "-i=busybox", "--unknown", "value"}, []string{"false", "busybox", "0", "us", "--unknown", "value"}},
+		{[]string{"wrap", "--all", "-l"}, []string{"false", "", "0", "us", "--all", "-l"}},
+	}
+	for _, tt := range tests {
+		got = nil
+		if err := app.Parse(tt.args); err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: dry, image, verbose, region, args = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	if err := app.Parse([]string{"wrap", "--image"}); err == nil {
+		t.Error("a known flag without its value: no error")
+	}
+}
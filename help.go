@@ -0,0 +1,464 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// default help using app.Out as its output
+func (a *App) PrintRootHelp() error {
+	return a.writeRootHelp(a.runOut())
+}
+
+func (a *App) writeRootHelp(w io.Writer) error {
+	if a.Version != "" {
+		fmt.Fprintf(w, "%s - v%s\n", a.Name, a.Version)
+	} else {
+		fmt.Fprintf(w, "%s\n", a.Name)
+	}
+
+	if a.Desc != "" {
+		fmt.Fprintf(w, "\n%s\n", a.Desc)
+	}
+
+	a.printCommands(w)
+	a.printTopics(w)
+
+	// the root command's own flags, see Root, then the globals
+	var rows [][2]string
+	var infos []FlagInfo
+	if a.root.cmd != nil {
+		infos = a.root.cmd.FlagInfos()
+	}
+	seen := make(map[string]bool)
+	for _, fi := range append(infos, a.GlobalFlagsInfo()...) {
+		if !seen[fi.GetName()] {
+			seen[fi.GetName()] = true
+			rows = append(rows, a.flagRow(fi))
+		}
+	}
+	writeFlagRows(w, "Flags", rows)
+
+	return nil
+}
+
+// PrintCommandHelp writes the help of cmd to app.Out: usage line,
+// description and flags.
+func (a *App) PrintCommandHelp(cmd *Command) error {
+	return a.writeCommandHelp(a.runOut(), cmd)
+}
+
+// writeHelp writes the help for cmd to w, root help for the root command.
+func (a *App) writeHelp(w io.Writer, cmd *Command) error {
+	if cmd == a.root.cmd {
+		return a.writeRootHelp(w)
+	}
+	return a.writeCommandHelp(w, cmd)
+}
+
+func (a *App) writeCommandHelp(w io.Writer, cmd *Command) error {
+	usage := cmd.Usage
+	if usage == "" {
+		usage = strings.TrimSpace(cmd.Name + " [flags] [args]")
+	}
+	// Usage is written relative to the parent command
+	if i := strings.LastIndex(cmd.path, " "); i >= 0 {
+		usage = cmd.path[:i] + " " + usage
+	}
+	fmt.Fprintf(w, "Usage:\n  %s %s\n", a.Name, usage)
+
+	switch {
+	case cmd.Long != "":
+		fmt.Fprintf(w, "\n%s\n", cmd.Long)
+	case cmd.Short != "":
+		fmt.Fprintf(w, "\n%s\n", cmd.Short)
+	}
+
+	if d := cmd.deprecated; d != nil {
+		fmt.Fprintf(w, "\nThis command is deprecated%s.\n", d.details())
+	}
+
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases:\n  %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintf(w, "\nExamples:\n")
+		for _, ex := range cmd.Examples {
+			for _, line := range strings.Split(strings.TrimRight(ex, "\n"), "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+
+	if n, ok := a.lookupNode(cmd.path); ok && len(n.child) > 0 {
+		fmt.Fprintf(w, "\nSubcommands:\n")
+		tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+		for _, name := range sortedKeys(n.child) {
+			if c := n.child[name].cmd; c != nil && !c.Hidden {
+				fmt.Fprintf(tw, "  %s\t%s\n", name, c.Short)
+			}
+		}
+		tw.Flush()
+	}
+
+	// local flags first, by group in order of first appearance; a local
+	// flag shadows a global of the same name
+	seen := make(map[string]bool)
+	groups := []string{""}
+	grouped := make(map[string][][2]string)
+	for _, fi := range cmd.FlagInfos() {
+		seen[fi.GetName()] = true
+		g := fi.GetGroup()
+		if _, ok := grouped[g]; !ok && g != "" {
+			groups = append(groups, g)
+		}
+		grouped[g] = append(grouped[g], a.flagRow(fi))
+	}
+
+	// plain stdlib flags added to cmd.Flags directly
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			switch f.Value.(type) {
+			case FlagInfo, negatedBool, renamedFlag:
+				return
+			}
+			typ, usage := flag.UnquoteUsage(f)
+			usage = joinNote(usage, defaultNote(f.DefValue))
+			grouped[""] = append(grouped[""], [2]string{strings.TrimSpace(flagNames(f.Name, nil, nil) + " " + typ), usage})
+		})
+	}
+
+	for _, g := range groups {
+		title := g
+		if title == "" {
+			title = "Flags"
+		}
+		writeFlagRows(w, title, grouped[g])
+	}
+
+	// globals and persistent flags of parent commands
+	var rows [][2]string
+	for _, f := range a.inheritedFlags(cmd) {
+		if fi, ok := f.(FlagInfo); ok && !seen[fi.GetName()] {
+			rows = append(rows, a.flagRow(fi))
+		}
+	}
+	writeFlagRows(w, "Global Flags", rows)
+
+	return nil
+}
+
+// SetCategoryOrder sets the order of the category sections in root
+// help. Categories not listed follow alphabetically; uncategorised
+// commands come first unless "" is listed.
+func (a *App) SetCategoryOrder(names ...string) *App {
+	a.catOrder = names
+	return a
+}
+
+// categories returns the keys of groups in display order, see
+// SetCategoryOrder.
+func (a *App) categories(groups map[string][]string) []string {
+	var out []string
+	if _, ok := groups[""]; ok && !slices.Contains(a.catOrder, "") {
+		out = append(out, "")
+	}
+	for _, cat := range a.catOrder {
+		if _, ok := groups[cat]; ok && !slices.Contains(out, cat) {
+			out = append(out, cat)
+		}
+	}
+	for _, cat := range sortedKeys(groups) {
+		if !slices.Contains(out, cat) {
+			out = append(out, cat)
+		}
+	}
+	return out
+}
+
+// printCommands lists every registered command grouped by category,
+// see SetCategoryOrder. Within a category commands are ordered by
+// their Order weight, then by path.
+func (a *App) printCommands(w io.Writer) {
+	groups := make(map[string][]string)
+	shorts := make(map[string]string)
+	order := make(map[string]int)
+
+	a.WalkCommands(func(path string, cmd *Command) {
+		if cmd == nil || cmd.Hidden {
+			return
+		}
+		groups[cmd.Category] = append(groups[cmd.Category], path)
+		shorts[path] = cmd.Short
+		if cmd.deprecated != nil {
+			shorts[path] = strings.TrimSpace("(deprecated) " + cmd.Short)
+		}
+		order[path] = cmd.order
+	})
+
+	for _, cat := range a.categories(groups) {
+		title := cat
+		if title == "" {
+			title = "Commands"
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+
+		paths := groups[cat]
+		sort.Slice(paths, func(i, j int) bool {
+			if order[paths[i]] != order[paths[j]] {
+				return order[paths[i]] < order[paths[j]]
+			}
+			return paths[i] < paths[j]
+		})
+
+		// the short text gets what the indent, widest path and
+		// column gap leave of the line
+		widest := 0
+		for _, p := range paths {
+			widest = max(widest, displayWidth(p))
+		}
+		room := a.helpWidth(w) - 2 - widest - 3
+
+		tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+		for _, p := range paths {
+			fmt.Fprintf(tw, "  %s\t%s\n", p, truncate(shorts[p], room))
+		}
+		tw.Flush()
+	}
+}
+
+// defaultHelpWidth is the line width assumed when output is not a
+// terminal.
+const defaultHelpWidth = 80
+
+// helpWidth returns the line width for help written to w: the
+// FluxHelpWidth setting, else the terminal width, else 80.
+func (a *App) helpWidth(w io.Writer) int {
+	if a.config.helpWidth > 0 {
+		return a.config.helpWidth
+	}
+	if f, ok := outFile(w); ok {
+		if n, ok := termWidth(f.Fd()); ok {
+			return n
+		}
+	}
+	return defaultHelpWidth
+}
+
+// truncate cuts s to its first line and at most n columns, counted
+// like table cells (see displayWidth), marking a cut with an ellipsis.
+// Nothing is cut when n is too small to be useful.
+func truncate(s string, n int) string {
+	s, _, multi := strings.Cut(s, "\n")
+	if n < 10 {
+		return s
+	}
+	if displayWidth(s) <= n && !multi {
+		return s
+	}
+	return strings.TrimRight(cutWidth(s, n-1), " ") + "…"
+}
+
+// helpCommand is the Action of the builtin "help" command.
+// With --json the output is the HelpJSON document (or the command's
+// part of it).
+func helpCommand(c *Context) error {
+	asJSON := c.GetBool("json")
+
+	if len(c.Args()) == 0 {
+		if asJSON {
+			return c.App.HelpJSON(c.Out())
+		}
+		return c.App.writeRootHelp(c.Out())
+	}
+
+	path := c.Args().String()
+	cmd, ok := c.App.Lookup(path)
+	if !ok {
+		// commands win over topics of the same name
+		if t, ok := c.App.topics[path]; ok {
+			if asJSON {
+				return writeJSON(c.Out(), t)
+			}
+			return c.App.writeTopic(c.Out(), t)
+		}
+		return fmt.Errorf("unknown help topic %q", path)
+	}
+	if asJSON {
+		return c.App.commandHelpJSON(c.Out(), cmd)
+	}
+	return c.App.writeCommandHelp(c.Out(), cmd)
+}
+
+// flagRow renders the names and usage columns of a flag. Like
+// flag.UnquoteUsage, a `quoted` word in the usage names the value.
+func (a *App) flagRow(fi FlagInfo) [2]string {
+	var typ string
+	if t, ok := fi.(interface{ typeName() string }); ok {
+		typ = t.typeName()
+	}
+
+	usage := fi.GetUsage()
+	if i := strings.IndexByte(usage, '`'); i >= 0 {
+		if j := strings.IndexByte(usage[i+1:], '`'); j >= 0 {
+			typ = usage[i+1 : i+1+j]
+			usage = usage[:i] + typ + usage[i+1+j+1:]
+		}
+	}
+
+	names := flagNames(fi.GetName(), fi.GetShort(), fi.GetAliases())
+	if n, ok := fi.(interface{ negatedName() string }); ok && n.negatedName() != "" {
+		names += ", --" + n.negatedName()
+	}
+
+	return [2]string{strings.TrimSpace(names + " " + typ), joinNote(usage, a.flagNote(fi))}
+}
+
+// flagNote renders what help says about a flag's value: the
+// environment variables it reads, when env is one of the FlagSources,
+// then "required" or its default. With variables the default is the
+// one in effect, the value of a set variable or config entry, as in
+// "(env: PORT, default: 9000)".
+func (a *App) flagNote(fi FlagInfo) string {
+	var parts []string
+	def := fi.GetDefaultValue()
+
+	order := a.config.sources
+	if order == nil {
+		order = defaultSources
+	}
+	if mf, ok := fi.(interface{ meta() *flagMeta }); ok && len(mf.meta().env) > 0 && slices.Contains(order, SourceEnv) {
+		parts = append(parts, "env: "+strings.Join(mf.meta().env, " or "))
+		if src, v, _ := a.pickSource(mf.meta(), order); src != SourceDefault {
+			def = v
+		}
+	}
+
+	switch {
+	case fi.IsRequired():
+		parts = append(parts, "required")
+	case !zeroDefault(def):
+		parts = append(parts, "default: "+def)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// defaultNote renders "(default: v)", nothing for a zero value: empty,
+// 0, false or 0s.
+func defaultNote(v string) string {
+	if zeroDefault(v) {
+		return ""
+	}
+	return "(default: " + v + ")"
+}
+
+// zeroDefault reports whether v is a default help leaves out, and
+// App.Validate accepts on a required flag.
+func zeroDefault(v string) bool {
+	switch v {
+	case "", "0", "false", "0s":
+		return true
+	}
+	return false
+}
+
+// joinNote appends note to usage with a space, if there is a note.
+func joinNote(usage, note string) string {
+	if note == "" {
+		return usage
+	}
+	return strings.TrimSpace(usage + " " + note)
+}
+
+// writeFlagRows writes an aligned flag section, nothing if rows is empty.
+func writeFlagRows(w io.Writer, title string, rows [][2]string) {
+	if len(rows) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	for _, r := range rows {
+		if r[1] == "" {
+			fmt.Fprintf(tw, "  %s\n", r[0])
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", r[0], r[1])
+	}
+	tw.Flush()
+}
+
+// flagNames renders "-s, --name, --alias" style flag names.
+func flagNames(name string, short, aliases []string) string {
+	var parts []string
+	for _, s := range short {
+		parts = append(parts, "-"+s)
+	}
+	for _, n := range append([]string{name}, aliases...) {
+		if len(n) == 1 {
+			parts = append(parts, "-"+n)
+		} else {
+			parts = append(parts, "--"+n)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// helpTopic is a page of conceptual help, see AddHelpTopic.
+type helpTopic struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// AddHelpTopic registers a help page that is not a command, shown by
+// "help <name>" and listed under "Help Topics" in root help. A command
+// with the same name takes precedence.
+func (a *App) AddHelpTopic(name, title, body string) *App {
+	if a.topics == nil {
+		a.topics = make(map[string]helpTopic)
+	}
+	a.topics[name] = helpTopic{Name: name, Title: title, Body: body}
+	return a
+}
+
+func (a *App) printTopics(w io.Writer) {
+	if len(a.topics) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nHelp Topics:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	for _, name := range sortedKeys(a.topics) {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, a.topics[name].Title)
+	}
+	tw.Flush()
+}
+
+func (a *App) writeTopic(w io.Writer, t helpTopic) error {
+	if t.Title != "" {
+		fmt.Fprintf(w, "%s\n\n", t.Title)
+	}
+	_, err := fmt.Fprintf(w, "%s\n", strings.TrimRight(t.Body, "\n"))
+	return err
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestHelpTopics(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	app.AddHelpTopic("config", "Configuration files", "Settings live in ~/.apprc.")
+	app.AddHelpTopic("deploy", "Deploying", "topic body")
+	mustCommand(t, app, "deploy", func(*Context) error { return nil }, Short("ship it"))
+
+	tests := []struct {
+		args []string
+		want string
+		not  string
+	}{
+		{[]string{"help", "config"}, "Settings live in ~/.apprc.", ""},
+		{[]string{"help", "deploy"}, "ship it", "topic body"},
+		{[]string{"help"}, "Help Topics:\n  config   Configuration files\n  deploy   Deploying\n", ""},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		if err := app.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q): %v", tt.args, err)
+		}
+		if !strings.Contains(out.String(), tt.want) || tt.not != "" && strings.Contains(out.String(), tt.not) {
+			t.Errorf("Parse(%q) printed:\n%s\nwant %q, not %q", tt.args, out, tt.want, tt.not)
+		}
+	}
+
+	if err := app.Parse([]string{"help", "bogus"}); err == nil || !strings.Contains(err.Error(), `unknown help topic "bogus"`) {
+		t.Errorf("help bogus: error = %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 20, "short"},
+		{"exactly ten", 11, "exactly ten"},
+		{"one char too long", 16, "one char too lo…"},
+		{"cut before a space", 11, "cut before…"},
+		{"first line\nsecond", 40, "first line…"},
+		{"tiny room", 5, "tiny room"},
+		{"héllo wörld ünïcode", 12, "héllo wörld…"},
+		{"日本語のテキストですね", 10, "日本語の…"},
+		{"日本語", 10, "日本語"},
+		{"cafe\u0301 au lait cre\u0300me", 12, "cafe\u0301 au lai…"},
+		{"cafe\u0301 au lait", 12, "cafe\u0301 au lait"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if tt.n >= 10 && displayWidth(got) > tt.n {
+			t.Errorf("truncate(%q, %d) = %q is longer than %d", tt.s, tt.n, got, tt.n)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tt.s, tt.n, got)
+		}
+	}
+}
+
+func TestHelpListingWidth(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxHelpWidth(40))
+	mustCommand(t, app, "deploy", func(*Context) error { return nil },
+		Short("deploy the application to every configured region at once"))
+	mustCommand(t, app, "sync", func(*Context) error { return nil },
+		Short("すべての設定済みリージョンへ一度にデプロイします"))
+
+	if err := app.Parse([]string{"help"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"deploy", "sync"} {
+		i := strings.Index(out.String(), "  "+name)
+		if i < 0 {
+			t.Errorf("%s not listed:\n%s", name, out)
+			continue
+		}
+		line, _, _ := strings.Cut(out.String()[i:], "\n")
+		if n := displayWidth(line); n > 40 || !strings.HasSuffix(line, "…") {
+			t.Errorf("listing line %q: %d columns, want at most 40 ending in …", line, n)
+		}
+	}
+}
+
+func TestCategoryOrder(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxNoBuiltins())
+	noop := func(*Context) error { return nil }
+	mustCommand(t, app, "zeta", noop, Category("misc"))
+	mustCommand(t, app, "build", noop, Category("dev"), Order(2))
+	mustCommand(t, app, "test", noop, Category("dev"), Order(1))
+	mustCommand(t, app, "lint", noop, Category("dev"))
+	mustCommand(t, app, "deploy", noop, Category("ops"))
+	mustCommand(t, app, "alpha", noop, Category("admin"))
+	mustCommand(t, app, "plain", noop)
+	app.SetCategoryOrder("ops", "dev", "unused")
+
+	if err := app.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" && line != "app" {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		"Commands:", "plain",
+		"ops:", "deploy",
+		"dev:", "lint", "test", "build",
+		"admin:", "alpha",
+		"misc:", "zeta",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("help lines = %q, want %q", got, want)
+	}
+}
+
+func TestHelpWithMissingRequiredFlag(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	ran := false
+	mustCommand(t, app, "deploy", func(*Context) error { ran = true; return nil },
+		Flags(String("region").Required().Help("target region"), Int("replicas").Range(1, 3).Env("APP_REPLICAS")))
+	t.Setenv("APP_REPLICAS", "bogus")
+
+	for _, flag := range []string{"--help", "-h"} {
+		out.Reset()
+		if err := app.Execute([]string{"deploy", flag}); err != nil {
+			t.Errorf("deploy %s: %v", flag, err)
+		}
+		if !strings.Contains(out.String(), "--region") || !strings.Contains(out.String(), "target region") {
+			t.Errorf("deploy %s printed:\n%s", flag, out)
+		}
+	}
+	if ran {
+		t.Error("the action ran with --help")
+	}
+}
+
+func TestHelpDefaults(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	mustCommand(t, app, "run", func(*Context) error { return nil }, Flags(
+		String("name").Default("web"), String("label"),
+		Bool("color").Default(true), Bool("quiet"),
+		Int("workers").Default(4), Int("retries"),
+		Float64("ratio").Default(1.5), Float64("scale"),
+		Duration("wait").Default(time.Second), Duration("delay"),
+		Count("level").Default(2), Count("depth"),
+		Bytes("limit").Default(1024), Bytes("buffer"),
+		Path("root").Default("/srv"), Path("dir"),
+		Time("since", "2006-01-02").Default(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)), Time("until", ""),
+		URLFlag("endpoint").Default("https://example.com"), URLFlag("proxy"),
+		IP("bind").Default("127.0.0.1"), IP("peer"),
+		CIDR("allow").Default("10.0.0.0/8"), CIDR("deny"),
+		EnumSlice("formats", []string{"json", "yaml"}).Default("json", "yaml"), EnumSlice("only", []string{"json"}),
+		HeaderFlag("header").Default([2]string{"Accept", "text/plain"}), HeaderFlag("extra"),
+		String("token").Default("secret").Required(),
+	))
+	if err := app.Execute([]string{"run", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"name": "(default: web)", "label": "",
+		"color": "(default: true)", "quiet": "",
+		"workers": "(default: 4)", "retries": "",
+		"ratio": "(default: 1.5)", "scale": "",
+		"wait": "(default: 1s)", "delay": "",
+		"level": "(default: 2)", "depth": "",
+		"limit": "(default: 1024)", "buffer": "",
+		"root": "(default: /srv)", "dir": "",
+		"since": "(default: 2024-01-02)", "until": "",
+		"endpoint": "(default: https://example.com)", "proxy": "",
+		"bind": "(default: 127.0.0.1)", "peer": "",
+		"allow": "(default: 10.0.0.0/8)", "deny": "",
+		"formats": "(default: json,yaml)", "only": "",
+		"header": "(default: Accept:text/plain)", "extra": "",
+		"token": "(required)",
+	}
+	lines := strings.Split(out.String(), "\n")
+	for name, want := range tests {
+		i := slices.IndexFunc(lines, func(l string) bool {
+			f := strings.Fields(l)
+			return len(f) > 0 && f[0] == "--"+name
+		})
+		if i < 0 {
+			t.Errorf("--%s missing from help:\n%s", name, out)
+			continue
+		}
+		line := lines[i]
+		if want == "" {
+			if strings.Contains(line, "(") {
+				t.Errorf("--%s line = %q, want no note", name, line)
+			}
+			continue
+		}
+		if !strings.HasSuffix(line, want) {
+			t.Errorf("--%s line = %q, want suffix %q", name, line, want)
+		}
+	}
+}
+
+func TestHelpEnvDefaults(t *testing.T) {
+	for _, k := range []string{"APP_PORT", "PORT", "APP_TOKEN", "APP_NAME"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+	app, out, _ := newTestApp(t)
+	mustCommand(t, app, "serve", func(*Context) error { return nil }, Flags(
+		Int("port").Default(8080).Env("APP_PORT", "PORT"),
+		String("token").Env("APP_TOKEN").Required(),
+		String("name").Env("APP_NAME"),
+	))
+
+	help := func() string {
+		t.Helper()
+		out.Reset()
+		if err := app.Execute([]string{"serve", "--help"}); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	check := func(when string, want ...string) {
+		t.Helper()
+		h := help()
+		for _, w := range want {
+			if !strings.Contains(h, w) {
+				t.Errorf("%s: help misses %q:\n%s", when, w, h)
+			}
+		}
+	}
+
+	check("unset", "(env: APP_PORT or PORT, default: 8080)", "(env: APP_TOKEN, required)", "(env: APP_NAME)")
+
+	os.Setenv("PORT", "9000")
+	os.Setenv("APP_NAME", "web")
+	check("set", "(env: APP_PORT or PORT, default: 9000)", "(env: APP_NAME, default: web)")
+
+	app.FlagSources(SourceCLI, SourceDefault)
+	if h := help(); strings.Contains(h, "env:") || !strings.Contains(h, "(default: 8080)") {
+		t.Errorf("without SourceEnv:\n%s", h)
+	}
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonFlag is the JSON form of a FlagInfo.
+type jsonFlag struct {
+	Name     string   `json:"name"`
+	Short    []string `json:"short,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Usage    string   `json:"usage,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Bool     bool     `json:"bool,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// jsonCommand is the JSON form of a command and its subcommands.
+type jsonCommand struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Usage    string        `json:"usage,omitempty"`
+	Short    string        `json:"short,omitempty"`
+	Long     string        `json:"long,omitempty"`
+	Category string        `json:"category,omitempty"`
+	Aliases  []string      `json:"aliases,omitempty"`
+	Examples []string      `json:"examples,omitempty"`
+	Hidden   bool          `json:"hidden,omitempty"`
+	Flags    []jsonFlag    `json:"flags,omitempty"`
+	Commands []jsonCommand `json:"commands,omitempty"`
+}
+
+// jsonApp is the document written by HelpJSON.
+type jsonApp struct {
+	Name     string        `json:"name"`
+	Version  string        `json:"version,omitempty"`
+	Desc     string        `json:"description,omitempty"`
+	Flags    []jsonFlag    `json:"flags,omitempty"`
+	Commands []jsonCommand `json:"commands,omitempty"`
+}
+
+// VersionJSON writes {"name": ..., "version": ...} to w.
+func (a *App) VersionJSON(w io.Writer) error {
+	return writeJSON(w, struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}{a.Name, a.Version})
+}
+
+// HelpJSON writes the app description, global flags and the whole
+// command tree as JSON to w. Commands are sorted by name; hidden ones
+// are left out.
+func (a *App) HelpJSON(w io.Writer) error {
+	return writeJSON(w, a.jsonApp(false))
+}
+
+// DumpTree writes the complete command tree as JSON to w for external
+// tooling, like HelpJSON but including hidden commands. Field names and
+// ordering are stable, so dumps can be diffed.
+func (a *App) DumpTree(w io.Writer) error {
+	return writeJSON(w, a.jsonApp(true))
+}
+
+func (a *App) jsonApp(hidden bool) jsonApp {
+	return jsonApp{
+		Name:     a.Name,
+		Version:  a.Version,
+		Desc:     a.Desc,
+		Flags:    toJSONFlags(a.GlobalFlagsInfo()),
+		Commands: a.jsonChildren(a.root, "", hidden),
+	}
+}
+
+// commandHelpJSON writes a single command and its subtree as JSON.
+func (a *App) commandHelpJSON(w io.Writer, cmd *Command) error {
+	n, _ := a.lookupNode(cmd.path)
+	return writeJSON(w, a.jsonCommand(cmd.path, n, false))
+}
+
+// jsonChildren converts the children of n, skipping hidden commands
+// unless hidden is set.
+func (a *App) jsonChildren(n *node, prefix string, hidden bool) []jsonCommand {
+	var out []jsonCommand
+	for _, name := range sortedKeys(n.child) {
+		path := name
+		if prefix != "" {
+			path = prefix + " " + name
+		}
+		child := n.child[name]
+		if !hidden && child.cmd != nil && child.cmd.Hidden {
+			continue
+		}
+		out = append(out, a.jsonCommand(path, child, hidden))
+	}
+	return out
+}
+
+func (a *App) jsonCommand(path string, n *node, hidden bool) jsonCommand {
+	jc := jsonCommand{Path: path, Commands: a.jsonChildren(n, path, hidden)}
+	if c := n.cmd; c != nil {
+		jc.Name = c.Name
+		jc.Usage = c.Usage
+		jc.Short = c.Short
+		jc.Long = c.Long
+		jc.Category = c.Category
+		jc.Aliases = c.Aliases
+		jc.Examples = c.Examples
+		jc.Hidden = c.Hidden
+		jc.Flags = toJSONFlags(c.FlagInfos())
+	}
+	return jc
+}
+
+func toJSONFlags(infos []FlagInfo) []jsonFlag {
+	var out []jsonFlag
+	for _, fi := range infos {
+		out = append(out, jsonFlag{
+			Name:     fi.GetName(),
+			Short:    fi.GetShort(),
+			Aliases:  fi.GetAliases(),
+			Usage:    fi.GetUsage(),
+			Default:  fi.GetDefaultValue(),
+			Bool:     fi.IsBool(),
+			Required: fi.IsRequired(),
+		})
+	}
+	return out
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// JSON writes v to Out as JSON followed by a newline: indented when
+// App.Out is a terminal, compact otherwise, so piped output stays one
+// line per value. Nothing is written when v does not marshal.
+func (c *Context) JSON(v any) error {
+	return emitJSON(c.Out(), v)
+}
+
+// JSONError is JSON for App.Err, e.g. for errors in machine readable
+// form.
+func (c *Context) JSONError(v any) error {
+	return emitJSON(c.App.Err, v)
+}
+
+// emitJSON encodes v in full before writing it to w, see Context.JSON.
+func emitJSON(w io.Writer, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if ttyOut(w) {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
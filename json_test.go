@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRootHelpJSON(t *testing.T) {
+	for _, args := range [][]string{
+		{"--help", "--json"},
+		{"--json", "-h"},
+		{"--region", "eu", "--help", "--json"},
+		{"help", "--json"},
+	} {
+		app, out, _ := newTestApp(t)
+		app.Flags(String("region"))
+		mustCommand(t, app, "greet", func(*Context) error { return nil }, Short("say hi"))
+
+		if err := app.Parse(args); err != nil {
+			t.Fatalf("Parse(%q): %v", args, err)
+		}
+		var tree struct {
+			Name     string `json:"name"`
+			Commands []struct {
+				Name string `json:"name"`
+			} `json:"commands"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &tree); err != nil {
+			t.Fatalf("Parse(%q) printed no JSON: %v\n%s", args, err, out)
+		}
+		if tree.Name != "app" || len(tree.Commands) == 0 {
+			t.Errorf("Parse(%q) = %+v", args, tree)
+		}
+	}
+}
+
+func TestDumpTree(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxNoBuiltins(), SetVersion("1.0"))
+	app.Flags(Bool("verbose", "v").Help("more output"))
+	mustCommand(t, app, "server", nil, Short("manage the server"), Category("ops"))
+	mustCommand(t, app, "server start", func(*Context) error { return nil },
+		Alias("up"), Flags(Int("port", "p").Default(80).Required()))
+	mustCommand(t, app, "debug", func(*Context) error { return nil }, Hidden())
+
+	var b strings.Builder
+	if err := app.DumpTree(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != wantTree {
+		t.Errorf("DumpTree =\n%s\nwant\n%s", b.String(), wantTree)
+	}
+}
+
+const wantTree = `{
+  "name": "app",
+  "version": "1.0",
+  "flags": [
+    {
+      "name": "verbose",
+      "short": [
+        "v"
+      ],
+      "usage": "more output",
+      "default": "false",
+      "bool": true
+    }
+  ],
+  "commands": [
+    {
+      "name": "debug",
+      "path": "debug",
+      "hidden": true
+    },
+    {
+      "name": "server",
+      "path": "server",
+      "short": "manage the server",
+      "category": "ops",
+      "commands": [
+        {
+          "name": "start",
+          "path": "server start",
+          "aliases": [
+            "up"
+          ],
+          "flags": [
+            {
+              "name": "port",
+              "short": [
+                "p"
+              ],
+              "default": "80",
+              "required": true
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+
+func TestContextJSON(t *testing.T) {
+	app, out, errOut := newTestApp(t)
+	c := &Context{App: app}
+	v := map[string]any{"name": "<web>", "ports": []int{80, 443}}
+
+	if err := c.JSON(v); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"<web>","ports":[80,443]}` + "\n"; out.String() != want {
+		t.Errorf("JSON wrote %q, want %q", out, want)
+	}
+	if err := c.JSONError(map[string]string{"error": "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"error":"boom"}` + "\n"; errOut.String() != want {
+		t.Errorf("JSONError wrote %q, want %q", errOut, want)
+	}
+
+	out.Reset()
+	if err := c.JSON(map[string]any{"ch": make(chan int)}); err == nil || out.Len() != 0 {
+		t.Errorf("unmarshalable value: error %v, wrote %q", err, out)
+	}
+
+	// a file that is not a terminal gets compact output too
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	app.Out = f
+	if err := c.JSON([]int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(f.Name()); string(data) != "[1,2]\n" {
+		t.Errorf("JSON to a file wrote %q", data)
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Logger is the minimal leveled logger used for the framework's own
+// diagnostics. Plug in anything (zap, zerolog, JSON writers...) through
+// FluxStructuredLogger.
+type Logger interface {
+	Debugf(format string, v ...any) // verbose and debug output
+	Infof(format string, v ...any)  // notices such as deprecation warnings
+	Errorf(format string, v ...any) // errors left to the default OnError
+}
+
+// StdLogger adapts a *log.Logger to Logger.
+// Info and Error lines are tagged with their level.
+func StdLogger(l *log.Logger) Logger {
+	return stdLogger{l: l}
+}
+
+type stdLogger struct {
+	l      *log.Logger
+	prefix string
+}
+
+func (s stdLogger) Debugf(format string, v ...any) {
+	s.l.Printf(s.prefix+format, v...)
+}
+
+func (s stdLogger) Infof(format string, v ...any) {
+	s.l.Printf(s.prefix+"INFO "+format, v...)
+}
+
+func (s stdLogger) Errorf(format string, v ...any) {
+	s.l.Printf(s.prefix+"ERROR "+format, v...)
+}
+
+// logger returns the configured Logger, falling back to the stdlib
+// logger with the usual "[name] " prefix.
+func (a *App) logger() Logger {
+	if a.config.logger != nil {
+		return a.config.logger
+	}
+	return stdLogger{l: a.config.log, prefix: "[" + a.Name + "] "}
+}
+
+// LevelTrace is the slog level used for trace output (see FluxTrace).
+const LevelTrace = slog.LevelDebug - 4
+
+// traceLevel is the log level of trace output: -vvv turns it on.
+const traceLevel = 3
+
+// debug logs msg with slog-style key/value attrs at level 1.
+// With FluxSlog the handler's level decides instead.
+func (a *App) debug(msg string, attrs ...any) {
+	a.log(1, msg, attrs...)
+}
+
+// verbose reports whether messages of the given level are shown: up
+// to the number of -v given (see FluxVerbosity), level 1 with
+// FluxDebug, everything in trace mode.
+func (a *App) verbose(level int) bool {
+	switch {
+	case a.config.trace:
+		return true
+	case a.config.debug && level <= 1:
+		return true
+	}
+	return level <= int(a.verbosity.Load())
+}
+
+// logf writes a formatted diagnostic message of the given level, 1
+// being the least chatty, see log.
+func (a *App) logf(level int, format string, args ...any) {
+	a.log(level, fmt.Sprintf(format, args...))
+}
+
+// trace logs verbose pipeline details (parsed and resolved flags) in
+// trace mode or at -vvv, through the same sink as debug.
+func (a *App) trace(msg string, attrs ...any) {
+	a.log(traceLevel, msg, attrs...)
+}
+
+// log is the single sink of the framework's diagnostics. Messages
+// above level 1 need the verbosity to allow them; with FluxSlog they
+// are then logged at a matching slog level, below slog.LevelDebug.
+func (a *App) log(level int, msg string, attrs ...any) {
+	if level > 1 && !a.verbose(level) {
+		return
+	}
+
+	if a.config.slog != nil {
+		lvl := slog.LevelDebug - slog.Level(level-1)*2
+		if level >= traceLevel {
+			lvl = LevelTrace
+		}
+		a.config.slog.Log(context.Background(), lvl, msg, attrs...)
+		return
+	}
+
+	if !a.verbose(level) {
+		return
+	}
+	if level >= traceLevel {
+		msg = "trace: " + msg
+	}
+	a.logger().Debugf("%s", formatAttrs(msg, attrs))
+}
+
+// warn prints a notice such as a deprecation warning to App.Err, and
+// records it with the configured slog or structured logger, if any.
+func (a *App) warn(format string, v ...any) {
+	msg := fmt.Sprintf(format, v...)
+	fmt.Fprintf(a.Err, "warning: %s\n", msg)
+
+	switch {
+	case a.config.slog != nil:
+		a.config.slog.Info(msg)
+	case a.config.logger != nil:
+		a.config.logger.Infof("%s", msg)
+	}
+}
+
+// reportError sends err to the configured slog or structured logger.
+// It reports false when neither is set.
+func (a *App) reportError(err error) bool {
+	switch {
+	case a.config.slog != nil:
+		a.config.slog.Error("command failed", "error", err)
+	case a.config.logger != nil:
+		a.config.logger.Errorf("%v", err)
+	default:
+		return false
+	}
+	return true
+}
+
+// formatAttrs renders msg followed by key=value pairs for text loggers.
+func formatAttrs(msg string, attrs []any) string {
+	var b strings.Builder
+	b.WriteString(msg)
+
+	for i := 0; i < len(attrs); i += 2 {
+		if i+1 == len(attrs) {
+			fmt.Fprintf(&b, " !BADKEY=%v", attrs[i])
+			break
+		}
+		fmt.Fprintf(&b, " %v=%v", attrs[i], attrs[i+1])
+	}
+	return b.String()
+}
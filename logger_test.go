@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVerbosityLevels(t *testing.T) {
+	tests := []struct {
+		flag string
+		want []string
+		not  []string
+	}{
+		{"", nil, []string{"command finished", "running Before", "trace: parsed flag"}},
+		{"-v", []string{"command finished"}, []string{"running Before", "trace: parsed flag"}},
+		{"-vv", []string{"command finished", "running Before"}, []string{"trace: parsed flag"}},
+		{"-vvv", []string{"command finished", "running Before", "trace: parsed flag"}, nil},
+	}
+	for _, tt := range tests {
+		var logs bytes.Buffer
+		app, _, _ := newTestApp(t, FluxVerbosity(true), FluxLogger(log.New(&logs, "", 0)))
+		mustCommand(t, app, "greet", func(*Context) error { return nil },
+			Before(func(*Context) error { return nil }))
+
+		args := []string{"greet"}
+		if tt.flag != "" {
+			args = append([]string{tt.flag}, args...)
+		}
+		if err := app.Parse(args); err != nil {
+			t.Fatalf("Parse(%q): %v", args, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(logs.String(), w) {
+				t.Errorf("%q: log lacks %q:\n%s", tt.flag, w, logs.String())
+			}
+		}
+		for _, n := range tt.not {
+			if strings.Contains(logs.String(), n) {
+				t.Errorf("%q: log has %q:\n%s", tt.flag, n, logs.String())
+			}
+		}
+	}
+}
+
+// recordHandler keeps every slog record it is given.
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+// find returns the first record with message msg and its attributes.
+func (h *recordHandler) find(msg string) (slog.Record, map[string]slog.Value, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		return r, attrs, true
+	}
+	return slog.Record{}, nil, false
+}
+
+func TestSlog(t *testing.T) {
+	h := new(recordHandler)
+	app, _, _ := newTestApp(t, FluxSlog(slog.New(h)), FluxTrace(true))
+	mustCommand(t, app, "greet", func(*Context) error { return errors.New("boom") },
+		Flags(String("name")))
+
+	if err := app.Execute([]string{"greet", "--name", "John"}); err == nil {
+		t.Fatal("Execute succeeded, want the action's error")
+	}
+
+	r, attrs, ok := h.find("command finished")
+	if !ok {
+		t.Fatalf("no command finished record in %d records", len(h.records))
+	}
+	if r.Level != slog.LevelDebug || attrs["command"].String() != "greet" {
+		t.Errorf("command finished: level %v, attrs %v", r.Level, attrs)
+	}
+	for _, key := range []string{"args", "duration", "error"} {
+		if _, ok := attrs[key]; !ok {
+			t.Errorf("command finished lacks the %s attribute: %v", key, attrs)
+		}
+	}
+
+	if r, attrs, ok := h.find("parsed flag"); !ok || r.Level != LevelTrace || attrs["value"].String() != "John" {
+		t.Errorf("parsed flag: found %v, level %v, attrs %v", ok, r.Level, attrs)
+	}
+	if r, _, ok := h.find(`running "greet" with args []`); !ok || r.Level != slog.LevelDebug-2 {
+		t.Errorf("running greet: found %v, level %v", ok, r.Level)
+	}
+	if r, attrs, ok := h.find("command failed"); !ok || r.Level != slog.LevelError || attrs["error"].String() != "boom" {
+		t.Errorf("command failed: found %v, level %v, attrs %v", ok, r.Level, attrs)
+	}
+}
+
+// levelLogger is a Logger recording each line with its level.
+type levelLogger struct{ lines []string }
+
+func (l *levelLogger) Debugf(format string, v ...any) { l.add("debug", format, v) }
+func (l *levelLogger) Infof(format string, v ...any)  { l.add("info", format, v) }
+func (l *levelLogger) Errorf(format string, v ...any) { l.add("error", format, v) }
+
+func (l *levelLogger) add(level, format string, v []any) {
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, v...))
+}
+
+func TestStructuredLoggerNotices(t *testing.T) {
+	l := new(levelLogger)
+	app, _, errOut := newTestApp(t, FluxStructuredLogger(l))
+	mustCommand(t, app, "list", func(*Context) error { return nil },
+		Deprecated(`use "get" instead`), Flags(Bool("all").Renamed("everything")))
+
+	if err := app.Execute([]string{"list", "--everything"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"info flag --everything is deprecated, use --all instead",
+		`info command "list" is deprecated: use "get" instead`,
+	}
+	if !slices.Equal(l.lines, want) {
+		t.Errorf("logged %q, want %q", l.lines, want)
+	}
+	for _, w := range want {
+		if !strings.Contains(errOut.String(), "warning: "+strings.TrimPrefix(w, "info ")) {
+			t.Errorf("App.Err lacks %q:\n%s", w, errOut)
+		}
+	}
+
+	h := new(recordHandler)
+	app, _, _ = newTestApp(t, FluxSlog(slog.New(h)))
+	mustCommand(t, app, "list", func(*Context) error { return nil }, Deprecated(""))
+	if err := app.Execute([]string{"list"}); err != nil {
+		t.Fatal(err)
+	}
+	if r, _, ok := h.find(`command "list" is deprecated`); !ok || r.Level != slog.LevelInfo {
+		t.Errorf("slog notice: found %v, level %v", ok, r.Level)
+	}
+}
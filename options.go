@@ -3,6 +3,8 @@ package cli
 import (
 	"io"
 	"log"
+	"log/slog"
+	"time"
 )
 
 // app config
@@ -25,6 +27,12 @@ func FluxDebug(on bool) ConfigOption {
 	return func(a *App) { a.config.debug = on }
 }
 
+// set trace, a more verbose debug mode that also reports command
+// resolution, parsed flags and hook firings
+func FluxTrace(on bool) ConfigOption {
+	return func(a *App) { a.config.trace = on }
+}
+
 // custom output for debug option
 // anything support io.Writer
 func FluxDebugOutput(w io.Writer) ConfigOption {
@@ -38,14 +46,191 @@ func FluxLogger(l *log.Logger) ConfigOption {
 	return func(a *App) { a.config.log = l }
 }
 
+// structured/leveled logger for debug output, deprecation notices
+// (also printed to App.Err) and the default OnError. Takes precedence
+// over FluxLogger.
+func FluxStructuredLogger(l Logger) ConfigOption {
+	return func(a *App) { a.config.logger = l }
+}
+
+// route internal debug, notice and error messages through log/slog with
+// structured attributes (command, args, duration, ...).
+// Takes precedence over FluxStructuredLogger and FluxLogger.
+func FluxSlog(l *slog.Logger) ConfigOption {
+	return func(a *App) { a.config.slog = l }
+}
+
 // set panic handler
 func FluxPanicHandler(fn func(any)) ConfigOption {
 	return func(a *App) { a.config.panicHandler = fn }
 }
 
+// set panic handler that also receives the stack trace captured at
+// recovery. Takes precedence over FluxPanicHandler.
+func FluxPanicHandlerStack(fn func(v any, stack []byte)) ConfigOption {
+	return func(a *App) { a.config.panicStack = fn }
+}
+
+// add a global --dry-run flag, read it with Context.DryRun. The
+// framework skips nothing itself; commands decide what to hold back.
+func FluxDryRun(on bool) ConfigOption {
+	return func(a *App) {
+		if on {
+			a.Flags(Bool("dry-run").Help("show what would be done without doing it."))
+		}
+	}
+}
+
+// add a global -v/--verbose count flag setting how chatty the
+// framework's own diagnostics are: -v shows the debug messages, -vv
+// adds command resolution and hook firings, -vvv every parsed flag
+func FluxVerbosity(on bool) ConfigOption {
+	return func(a *App) {
+		if on {
+			a.verboseFlag = Count("verbose", "v").Help("more diagnostic output, repeat for more.")
+			a.Flags(a.verboseFlag)
+		}
+	}
+}
+
+// flag values keyed by flag name, typically read from a config file.
+// They rank below the command line and environment, see
+// App.FlagSources.
+func FluxConfigValues(values map[string]string) ConfigOption {
+	return func(a *App) { a.config.values = values }
+}
+
+// start with an empty command tree: BuiltinPlugin is not adopted, so
+// there is no "help" or "version" command and no --help flag unless
+// you add them yourself
+func FluxNoBuiltins() ConfigOption {
+	return func(a *App) { a.config.noBuiltins = true }
+}
+
+// make Parse fail up front when a command without subcommands has no
+// Action, naming its path, instead of only when it is run
+func FluxMustHaveAction(on bool) ConfigOption {
+	return func(a *App) { a.config.needAction = on }
+}
+
+// let an unambiguous prefix stand for a command, e.g. "ser" for
+// "server"; exact names always win
+func FluxAbbreviations(on bool) ConfigOption {
+	return func(a *App) { a.config.abbrev = on }
+}
+
+// let an unambiguous prefix of a long flag stand for it, e.g. --ver
+// for --verbose; exact names and short flags are unaffected
+func FluxFlagAbbreviations(on bool) ConfigOption {
+	return func(a *App) { a.config.flagAbbrev = on }
+}
+
+// FlagErrorMode selects who reports flag parse errors, see
+// FluxFlagErrorHandling. Parsing always continues on error (the flag
+// package never exits the program); the error is returned either way.
+type FlagErrorMode int
+
+const (
+	// FlagErrorsQuiet leaves reporting to OnError (default).
+	FlagErrorsQuiet FlagErrorMode = iota
+	// FlagErrorsStdlib also lets the flag package print its error and
+	// usage dump, to App.Err.
+	FlagErrorsStdlib
+)
+
+// match command and flag names in any case, e.g. "app SERVER START
+// --VERBOSE"; names registered with the exact spelling win, and flag
+// values and arguments are passed on as typed
+func FluxCaseInsensitive(on bool) ConfigOption {
+	return func(a *App) { a.config.fold = on }
+}
+
+// choose how flag parse errors are presented
+func FluxFlagErrorHandling(mode FlagErrorMode) ConfigOption {
+	return func(a *App) { a.config.flagErrors = mode }
+}
+
+// line width for help listings; long command descriptions are cut
+// with an ellipsis to fit. By default the terminal width is used, or
+// 80 when output is not a terminal.
+func FluxHelpWidth(n int) ConfigOption {
+	return func(a *App) { a.config.helpWidth = n }
+}
+
+// pass everything written to App.Out, help, version and REPL output
+// included, through fn, e.g. to redact secrets or prefix lines. The
+// first run wraps App.Out in the filter, and wraps again whatever is
+// assigned to it later. fn sees one Write at a time (one per fmt.Fprint
+// call), even across concurrent runs, and may return a different
+// length; terminal detection and styling still look at the real
+// writer, so fn sees escape codes as written
+func FluxOutputFilter(fn func([]byte) []byte) ConfigOption {
+	return func(a *App) { a.config.outFilter = fn }
+}
+
+// make deprecated commands fail with ErrDeprecatedCommand instead of
+// warning, to check that scripts no longer use them
+func FluxDeprecatedAsErrors(on bool) ConfigOption {
+	return func(a *App) { a.config.noDeprecated = on }
+}
+
+// expand "@path" arguments to the arguments read from that file,
+// split on whitespace and quotes with backslashes kept literal;
+// response files may include others. Arguments after "--" or after
+// the path of a RawArgs command are left alone
+func FluxResponseFiles(on bool) ConfigOption {
+	return func(a *App) { a.config.respFiles = on }
+}
+
+// ask for required flags left out on the command line instead of
+// failing, when App.In is a terminal (or any reader other than a
+// file, e.g. one injected by tests); empty answers keep the usual
+// required-flag error
+func FluxInteractive(on bool) ConfigOption {
+	return func(a *App) { a.config.interactive = on }
+}
+
+// prompt shown by RunREPL (default "name> ")
+func FluxREPLPrompt(p string) ConfigOption {
+	return func(a *App) { a.config.replPrompt = p }
+}
+
+// maximum nesting depth for Context.Exec (default 32)
+func FluxMaxDepth(n int) ConfigOption {
+	return func(a *App) { a.config.maxDepth = n }
+}
+
 // config for command
 type CommandOption func(*Command)
 
+// fail the command when Action runs longer than d. Go cannot stop a
+// running goroutine, so Action must watch c.Ctx.Done() and return;
+// the command then fails with an error wrapping
+// context.DeadlineExceeded.
+func Timeout(d time.Duration) CommandOption {
+	return func(c *Command) { c.Timeout = d }
+}
+
+// run Action up to attempts times in total, waiting backoff between
+// tries. Only errors marked retryable are retried, see RetryIf.
+// Before and After still run once.
+func Retry(attempts int, backoff time.Duration) CommandOption {
+	return func(c *Command) {
+		p := c.retryPolicy()
+		p.attempts, p.backoff = attempts, backoff
+	}
+}
+
+// double the Retry backoff after each failed attempt
+func RetryExponential() CommandOption {
+	return func(c *Command) { c.retryPolicy().exponential = true }
+}
+
+// also retry errors for which fn returns true
+func RetryIf(fn func(error) bool) CommandOption {
+	return func(c *Command) { c.retryPolicy().retryIf = fn }
+}
+
 // execute before action
 func Before(fn func(*Context) error) CommandOption {
 	return func(c *Command) { c.Before = fn }
@@ -56,6 +241,11 @@ func After(fn func(*Context) error) CommandOption {
 	return func(c *Command) { c.After = fn }
 }
 
+// execute after After, only when nothing failed
+func OnSuccess(fn func(*Context) error) CommandOption {
+	return func(c *Command) { c.OnSuccess = fn }
+}
+
 // short description for defined command
 func Short(s string) CommandOption {
 	return func(c *Command) { c.Short = s }
@@ -66,6 +256,34 @@ func Long(s string) CommandOption {
 	return func(c *Command) { c.Long = s }
 }
 
+// mark the command deprecated: running it prints a warning to
+// App.Err with msg, e.g. `use "get" instead`, and help says so
+func Deprecated(msg string) CommandOption {
+	return func(c *Command) { c.deprecation().Msg = msg }
+}
+
+// version or date since which the command is deprecated, shown in the
+// warning and help; implies Deprecated
+func DeprecatedSince(since string) CommandOption {
+	return func(c *Command) { c.deprecation().Since = since }
+}
+
+// version or date in which the command goes away, shown in the
+// warning and help; implies Deprecated
+func RemovedIn(version string) CommandOption {
+	return func(c *Command) { c.deprecation().RemovedIn = version }
+}
+
+// keep the command out of help listings; it still runs
+func Hidden() CommandOption {
+	return func(c *Command) { c.Hidden = true }
+}
+
+// usage examples listed in the command's help
+func Example(examples ...string) CommandOption {
+	return func(c *Command) { c.Examples = append(c.Examples, examples...) }
+}
+
 // set aliases for command
 func Alias(a ...string) CommandOption {
 	return func(c *Command) { c.Aliases = a }
@@ -81,8 +299,45 @@ func Category(cat string) CommandOption {
 	return func(c *Command) { c.Category = cat }
 }
 
+// weight of the command within its category in help listings; lower
+// comes first, equal weights sort by path (default 0)
+func Order(n int) CommandOption {
+	return func(c *Command) { c.order = n }
+}
+
+// handle unknown subcommands anywhere below this command, before
+// SetFallback and App.OnNotFound; the nearest command with a handler
+// wins. Return ErrNotHandled to pass the name on.
+func NotFound(h NotFoundHandler) CommandOption {
+	return func(c *Command) { c.notFound = h }
+}
+
+// parse the command's known flags, globals included, but hand flags
+// it does not know to Action through Context.Args, in the order given,
+// instead of failing. Known flags may appear anywhere before a "--".
+// Handy for wrappers that forward most of their args, see also RawArgs.
+// Abbreviated flag names (FluxFlagAbbreviations) count as unknown.
+func AllowUnknownFlags() CommandOption {
+	return func(c *Command) { c.unknown = true }
+}
+
+// disable flag parsing: every token after the command path,
+// even "--help", is delivered verbatim through Context.Args. Global
+// flags before the path, as in "app --verbose wrap ls -l", are still
+// parsed. Handy for wrapper commands.
+func RawArgs() CommandOption {
+	return func(c *Command) { c.DisableFlagParsing = true }
+}
+
+// replace a command already registered at the same path instead of
+// failing with a duplicate error; its subcommands are kept
+func Override() CommandOption {
+	return func(c *Command) { c.override = true }
+}
+
 // help flag handler
-// Nil falls back to PrintRootHelp.
+// Nil falls back to PrintRootHelp for the root command and
+// PrintCommandHelp for everything else.
 func BuiltinHelpFlag(fn func(*Context) error) ConfigOption {
 	return func(a *App) { a.helpFlagAction = fn }
 }
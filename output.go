@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// filterWriter passes everything written through fn, see
+// FluxOutputFilter. Writes are serialized, so fn sees one at a time
+// even when runs share the writer.
+type filterWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	fn func([]byte) []byte
+}
+
+func (f *filterWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.w.Write(f.fn(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// outFile returns the file w writes to, looking through an output
+// filter, so terminal detection still sees the real stream.
+func outFile(w io.Writer) (*os.File, bool) {
+	if fw, ok := w.(*filterWriter); ok {
+		w = fw.w
+	}
+	f, ok := w.(*os.File)
+	return f, ok
+}
+
+// runOut returns App.Out with the output filter installed, if one is
+// set. The filter wraps App.Out in place, once for each writer assigned
+// to it, so writes straight to App.Out are filtered too.
+func (a *App) runOut() io.Writer {
+	if a.config.outFilter == nil {
+		return a.Out
+	}
+
+	a.outMu.Lock()
+	defer a.outMu.Unlock()
+	if _, ok := a.Out.(*filterWriter); !ok {
+		a.Out = &filterWriter{w: a.Out, fn: a.config.outFilter}
+	}
+	return a.Out
+}
+
+// Out returns the writer for the command's normal output, App.Out as
+// filtered by FluxOutputFilter.
+//
+//	fmt.Fprintln(c.Out(), "done")
+func (c *Context) Out() io.Writer {
+	if c.out != nil {
+		return c.out
+	}
+	return c.App.Out
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func redact(p []byte) []byte {
+	return bytes.ReplaceAll(p, []byte("SECRET"), []byte("******"))
+}
+
+func TestOutputFilterRedacts(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxOutputFilter(redact))
+	mustCommand(t, app, "show", func(c *Context) error {
+		fmt.Fprintln(c.Out(), "token=SECRET")
+		fmt.Fprintln(c.App.Out, "direct=SECRET")
+		return c.JSON(map[string]string{"token": "SECRET"})
+	})
+
+	if err := app.Parse([]string{"show"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "token=******\ndirect=******\n{\"token\":\"******\"}\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// a writer assigned later is wrapped on the next run
+	out = new(bytes.Buffer)
+	app.Out = out
+	if err := app.Parse([]string{"show"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "SECRET") {
+		t.Errorf("unfiltered output after replacing App.Out: %q", out)
+	}
+}
+
+func TestOutputFilterOutsideRuns(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxOutputFilter(func(p []byte) []byte {
+		return bytes.ToUpper(p)
+	}))
+	app.In = strings.NewReader("bogus\n")
+	mustCommand(t, app, "show", func(c *Context) error { return nil }, Short("shows things"))
+
+	if err := app.PrintRootHelp(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "SHOWS THINGS") {
+		t.Errorf("PrintRootHelp not filtered:\n%s", out)
+	}
+
+	out.Reset()
+	if err := app.RunREPL(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "app> ") || !strings.Contains(out.String(), "APP> ") {
+		t.Errorf("REPL output not filtered: %q", out)
+	}
+}
+
+func TestOutputFilterConcurrent(t *testing.T) {
+	app, _, _ := newTestApp(t, FluxOutputFilter(redact))
+	out := new(syncBuffer)
+	app.Out = out
+	mustCommand(t, app, "show", func(c *Context) error {
+		for range 10 {
+			io.WriteString(c.Out(), "SECRET\n")
+		}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := app.Parse([]string{"show"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if strings.Contains(out.String(), "SECRET") {
+		t.Error("unfiltered output leaked")
+	}
+	if n := strings.Count(out.String(), "******\n"); n != 80 {
+		t.Errorf("got %d filtered lines, want 80", n)
+	}
+}
+
+func TestOutputFilterHelp(t *testing.T) {
+	app, out, _ := newTestApp(t, FluxOutputFilter(func(p []byte) []byte {
+		return bytes.ToUpper(p)
+	}))
+	mustCommand(t, app, "show", func(c *Context) error { return nil }, Short("shows things"))
+
+	if err := app.Parse([]string{"show", "--help"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "SHOWS THINGS") {
+		t.Errorf("help not filtered:\n%s", out)
+	}
+}
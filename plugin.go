@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Plugin)
+)
+
+// RegisterPlugin makes a plugin available to App.UsePluginsByName under
+// name, typically from an init function. Registering a name twice
+// replaces the earlier factory.
+func RegisterPlugin(name string, factory func() Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// UsePluginsByName adopts the registered plugins with the given names,
+// in order, e.g. as listed in a config file. Unknown names are an error
+// and nothing is installed; an error from a plugin's Sparkle stops at
+// that plugin.
+func (a *App) UsePluginsByName(names ...string) error {
+	registryMu.RLock()
+	factories := make([]func() Plugin, 0, len(names))
+	for _, name := range names {
+		f, ok := registry[name]
+		if !ok {
+			registryMu.RUnlock()
+			return fmt.Errorf("unknown plugin %q", name)
+		}
+		factories = append(factories, f)
+	}
+	registryMu.RUnlock()
+
+	for i, f := range factories {
+		pl := f()
+		if pl == nil {
+			return fmt.Errorf("plugin %q: factory returned nil", names[i])
+		}
+		if err := a.install(pl); err != nil {
+			return fmt.Errorf("plugin %q: %w", names[i], err)
+		}
+	}
+	return nil
+}
+
+// Shutdown calls Shutdown on the installed plugins that implement
+// ShutdownPlugin, last installed first, and joins their errors. Only
+// the first call does anything, later ones return nil. Run and RunREPL
+// call it when they finish; a program driving the app with Execute
+// calls it once it is done with the app.
+func (a *App) Shutdown() error {
+	var errs []error
+	a.shutdownOnce.Do(func() {
+		for i := len(a.plugins) - 1; i >= 0; i-- {
+			sp, ok := a.plugins[i].(ShutdownPlugin)
+			if !ok {
+				continue
+			}
+			if err := sp.Shutdown(a); err != nil {
+				errs = append(errs, fmt.Errorf("plugin shutdown: %w", err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// finish runs Shutdown at the end of Run or RunREPL, handing its error
+// to OnError and joining it to err.
+func (a *App) finish(err error) error {
+	if serr := a.Shutdown(); serr != nil {
+		a.handleError(serr)
+		err = errors.Join(err, serr)
+	}
+	return err
+}
+
+// Plugins returns the installed plugins in install order. The slice is
+// a copy; changing it does not affect the app.
+func (a *App) Plugins() []Plugin {
+	return append([]Plugin(nil), a.plugins...)
+}
+
+// pluginName returns the plugin's Name() if it has one, else its type,
+// followed by its Version() if it has one.
+func pluginName(p Plugin) string {
+	name := fmt.Sprintf("%T", p)
+	if n, ok := p.(interface{ Name() string }); ok {
+		name = n.Name()
+	}
+	if v, ok := p.(interface{ Version() string }); ok && v.Version() != "" {
+		name += " " + v.Version()
+	}
+	return name
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testPlugin registers a command named after it and records its
+// lifecycle calls in log.
+type testPlugin struct {
+	name string
+	log  *[]string
+	err  error // returned by Shutdown
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) Sparkle(a *App) error {
+	*p.log = append(*p.log, "sparkle "+p.name)
+	_, err := a.Command(p.name, func(*Context) error { return nil })
+	return err
+}
+
+func (p *testPlugin) Shutdown(*App) error {
+	*p.log = append(*p.log, "shutdown "+p.name)
+	return p.err
+}
+
+func TestUsePluginsByName(t *testing.T) {
+	var log []string
+	for _, name := range []string{"test-metrics", "test-auth"} {
+		RegisterPlugin(name, func() Plugin { return &testPlugin{name: name, log: &log} })
+	}
+	RegisterPlugin("test-nil", func() Plugin { return nil })
+
+	app, _, _ := newTestApp(t)
+	if err := app.UsePluginsByName("test-metrics", "test-bogus"); err == nil || !strings.Contains(err.Error(), `unknown plugin "test-bogus"`) {
+		t.Errorf("unknown name: error = %v", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("unknown name installed %q", log)
+	}
+
+	if err := app.UsePluginsByName("test-auth", "test-metrics"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sparkle test-auth", "sparkle test-metrics"}; !slices.Equal(log, want) {
+		t.Errorf("installed %q, want %q", log, want)
+	}
+	for _, path := range []string{"test-auth", "test-metrics"} {
+		if _, ok := app.Lookup(path); !ok {
+			t.Errorf("plugin command %q missing", path)
+		}
+	}
+
+	if err := app.UsePluginsByName("test-nil"); err == nil || !strings.Contains(err.Error(), "factory returned nil") {
+		t.Errorf("nil factory: error = %v", err)
+	}
+}
+
+func TestPluginShutdown(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		var log []string
+		app, _, _ := newTestApp(t)
+		closeErr := errors.New("close failed")
+		app.Adopt(
+			&testPlugin{name: "db", log: &log, err: closeErr},
+			&testPlugin{name: "cache", log: &log},
+		)
+		boom := errors.New("boom")
+		mustCommand(t, app, "run", func(*Context) error {
+			log = append(log, "run")
+			if fail {
+				return boom
+			}
+			return nil
+		})
+
+		// plugins outlive Execute, so the app can run again
+		for range 2 {
+			if err := app.Execute([]string{"run"}); errors.Is(err, boom) != fail {
+				t.Errorf("fail=%v: Execute error = %v", fail, err)
+			}
+		}
+		if want := []string{"sparkle db", "sparkle cache", "run", "run"}; !slices.Equal(log, want) {
+			t.Errorf("fail=%v: calls %q, want %q", fail, log, want)
+		}
+
+		if err := app.Shutdown(); !errors.Is(err, closeErr) {
+			t.Errorf("fail=%v: Shutdown error = %v", fail, err)
+		}
+		if err := app.Shutdown(); err != nil {
+			t.Errorf("fail=%v: second Shutdown error = %v", fail, err)
+		}
+		if want := []string{"sparkle db", "sparkle cache", "run", "run", "shutdown cache", "shutdown db"}; !slices.Equal(log, want) {
+			t.Errorf("fail=%v: calls %q, want %q", fail, log, want)
+		}
+	}
+}
+
+func TestPluginShutdownAfterREPL(t *testing.T) {
+	var log []string
+	app, _, _ := newTestApp(t)
+	var handled []error
+	app.OnError = func(_ *Context, err error) error {
+		handled = append(handled, err)
+		return err
+	}
+	closeErr := errors.New("close failed")
+	app.Adopt(&testPlugin{name: "db", log: &log, err: closeErr})
+	app.In = strings.NewReader("db\ndb\nexit\n")
+
+	if err := app.RunREPL(); !errors.Is(err, closeErr) {
+		t.Errorf("RunREPL error = %v, want the shutdown error", err)
+	}
+	if want := []string{"sparkle db", "shutdown db"}; !slices.Equal(log, want) {
+		t.Errorf("calls %q, want %q", log, want)
+	}
+	if len(handled) != 1 || !errors.Is(handled[0], closeErr) {
+		t.Errorf("OnError got %v, want the shutdown error", handled)
+	}
+}
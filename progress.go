@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are drawn in turn, one per spinnerTick.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+const spinnerTick = 100 * time.Millisecond
+
+// Spinner animates next to a message while a long operation runs, see
+// Context.Spinner. Its methods are safe for concurrent use.
+type Spinner struct {
+	w    io.Writer
+	tty  bool
+	mu   sync.Mutex
+	msg  string
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+// Spinner starts a spinner showing msg on Out. It animates only
+// when App.Out is a terminal; otherwise msg is printed once as a plain
+// line and nothing else is written. Call Stop when the work is done.
+//
+//	s := c.Spinner("Downloading...")
+//	defer s.Stop()
+func (c *Context) Spinner(msg string) *Spinner {
+	s := &Spinner{w: c.Out(), tty: ttyOut(c.Out()), msg: msg, done: make(chan struct{})}
+	if !s.tty {
+		fmt.Fprintln(s.w, msg)
+		return s
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *Spinner) run() {
+	defer s.wg.Done()
+	t := time.NewTicker(spinnerTick)
+	defer t.Stop()
+
+	for i := 0; ; i++ {
+		s.mu.Lock()
+		fmt.Fprintf(s.w, "\r\x1b[K%s %s", spinnerFrames[i%len(spinnerFrames)], s.msg)
+		s.mu.Unlock()
+
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Update replaces the message. Off a terminal it is not printed.
+func (s *Spinner) Update(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msg = msg
+}
+
+// Stop ends the animation and clears its line. Calling it again does
+// nothing.
+func (s *Spinner) Stop() {
+	s.stop.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		if s.tty {
+			fmt.Fprint(s.w, "\r\x1b[K")
+		}
+	})
+}
+
+// progressWidth is the number of cells in a progress bar.
+const progressWidth = 30
+
+// ProgressBar shows how far a task of known size has come, see
+// Context.ProgressBar. Its methods are safe for concurrent use.
+type ProgressBar struct {
+	w        io.Writer
+	tty      bool
+	mu       sync.Mutex
+	cur, max int
+	finished bool
+}
+
+// ProgressBar starts a bar for total steps on Out. It is drawn only
+// when App.Out is a terminal; elsewhere nothing is written, so output
+// piped to a file or another program stays clean.
+//
+//	bar := c.ProgressBar(len(files))
+//	for _, f := range files {
+//		process(f)
+//		bar.Increment()
+//	}
+//	bar.Finish()
+func (c *Context) ProgressBar(total int) *ProgressBar {
+	p := &ProgressBar{w: c.Out(), tty: ttyOut(c.Out()), max: max(total, 0)}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw()
+	return p
+}
+
+// Increment advances the bar by one step.
+func (p *ProgressBar) Increment() {
+	p.Add(1)
+}
+
+// Add advances the bar by n steps, stopping at the total.
+func (p *ProgressBar) Add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	cur := min(max(p.cur+n, 0), p.max)
+	if cur == p.cur {
+		return
+	}
+	p.cur = cur
+	p.draw()
+}
+
+// Finish fills the bar and ends its line. Later updates are ignored.
+func (p *ProgressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	p.cur, p.finished = p.max, true
+	p.draw()
+	if p.tty {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// draw redraws the bar in place; the caller holds p.mu.
+func (p *ProgressBar) draw() {
+	if !p.tty {
+		return
+	}
+	filled, pct := progressWidth, 100
+	if p.max > 0 {
+		filled = p.cur * progressWidth / p.max
+		pct = p.cur * 100 / p.max
+	}
+	fmt.Fprintf(p.w, "\r[%s%s] %3d%% (%d/%d)",
+		strings.Repeat("#", filled), strings.Repeat("-", progressWidth-filled), pct, p.cur, p.max)
+}
+
+// ttyOut reports whether w is a terminal.
+func ttyOut(w io.Writer) bool {
+	f, ok := outFile(w)
+	return ok && isTerminal(f.Fd())
+}
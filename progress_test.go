@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgressNotTerminal(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	c := &Context{App: app}
+
+	s := c.Spinner("Downloading...")
+	s.Update("Still downloading...")
+	s.Stop()
+	s.Stop()
+
+	bar := c.ProgressBar(100)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				bar.Increment()
+			}
+		}()
+	}
+	wg.Wait()
+	bar.Finish()
+	bar.Add(5)
+
+	if got := out.String(); got != "Downloading...\n" {
+		t.Errorf("printed %q, want only the spinner message", got)
+	}
+	if strings.ContainsAny(out.String(), "\r\x1b") {
+		t.Errorf("output has control characters: %q", out)
+	}
+	if bar.cur != 100 {
+		t.Errorf("bar at %d, want 100", bar.cur)
+	}
+}
+
+func TestProgressDraw(t *testing.T) {
+	var b strings.Builder
+	p := &ProgressBar{w: &b, tty: true, max: 4}
+	p.Add(1)
+	p.Add(10)
+	p.Finish()
+	want := "\r[#######-----------------------]  25% (1/4)" +
+		"\r[##############################] 100% (4/4)" +
+		"\r[##############################] 100% (4/4)\n"
+	if b.String() != want {
+		t.Errorf("drew %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Prompt writes prompt to Out and reads one line from App.In,
+// without the trailing newline. Hitting EOF before any input returns
+// io.EOF; Ctrl-C, a closed App.In or the end of the command's Ctx
+// return ErrInterrupted.
+func (c *Context) Prompt(prompt string) (string, error) {
+	fmt.Fprint(c.Out(), prompt)
+	return c.App.readLineCtx(c.baseCtx())
+}
+
+// Confirm asks a yes/no question, appending " [y/N] " to prompt.
+// y/yes and n/no are accepted in any case, empty input means no and
+// anything else asks again.
+func (c *Context) Confirm(prompt string) (bool, error) {
+	for {
+		ans, err := c.Prompt(prompt + " [y/N] ")
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(ans)) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// readLine reads a line from App.In. The buffered reader is kept so
+// consecutive prompts don't lose input, and rebuilt if In changes.
+func (a *App) readLine() (string, error) {
+	if a.in == nil || a.inSrc != a.In {
+		a.in = bufio.NewReader(a.In)
+		a.inSrc = a.In
+	}
+
+	line, err := a.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineRead is the outcome of a readLine.
+type lineRead struct {
+	line string
+	err  error
+}
+
+// readLineCtx is readLine that gives up with ErrInterrupted on Ctrl-C
+// or when ctx ends. The read itself cannot be stopped; it goes on in
+// the background and its line is handed to the next caller instead of
+// being lost.
+func (a *App) readLineCtx(ctx context.Context) (string, error) {
+	res := a.pending
+	a.pending = nil
+	if res == nil {
+		res = make(chan lineRead, 1)
+		go func() {
+			line, err := a.readLine()
+			res <- lineRead{line, err}
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	select {
+	case r := <-res:
+		if errors.Is(r.err, io.ErrClosedPipe) || errors.Is(r.err, os.ErrClosed) {
+			return "", ErrInterrupted
+		}
+		return r.line, r.err
+	case <-sig:
+		a.pending = res
+		return "", ErrInterrupted
+	case <-ctx.Done():
+		a.pending = res
+		return "", fmt.Errorf("%w: %w", ErrInterrupted, ctx.Err())
+	}
+}
+
+// PromptPassword is like Prompt but turns off echo while reading when
+// App.In is a terminal, printing a newline afterwards. Other inputs
+// (pipes, tests) are read as a plain line.
+func (c *Context) PromptPassword(prompt string) (string, error) {
+	f, ok := c.App.In.(*os.File)
+	if !ok || !isTerminal(f.Fd()) {
+		return c.Prompt(prompt)
+	}
+
+	fmt.Fprint(c.Out(), prompt)
+
+	var line string
+	err := withoutEcho(f.Fd(), func() (err error) {
+		line, err = c.App.readLineCtx(c.baseCtx())
+		return err
+	})
+	fmt.Fprintln(c.Out())
+
+	return line, err
+}
+
+// interactiveIn reports whether App.In can answer prompts: a terminal,
+// or a reader that is not a file at all.
+func (a *App) interactiveIn() bool {
+	if f, ok := a.In.(*os.File); ok {
+		return isTerminal(f.Fd())
+	}
+	return a.In != nil
+}
+
+// promptRequired asks for every required typed flag that has no value
+// yet, see FluxInteractive. Answered flags report SourcePrompt. An
+// invalid answer is reported and asked again; an empty one or EOF
+// leaves the flag to the required check.
+func (c *Context) promptRequired() error {
+	seen := make(map[*flagMeta]bool)
+	var missing []*flag.Flag
+	metas := make(map[*flag.Flag]*flagMeta)
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		mf, ok := f.Value.(interface{ meta() *flagMeta })
+		if !ok {
+			return
+		}
+		m := mf.meta()
+		if f.Name != m.name || seen[m] || !m.required || m.source != SourceDefault {
+			return
+		}
+		seen[m] = true
+		missing = append(missing, f)
+		metas[f] = m
+	})
+
+	for _, f := range missing {
+		prompt := f.Name
+		if f.Usage != "" {
+			prompt += " (" + f.Usage + ")"
+		}
+		for {
+			ans, err := c.Prompt(prompt + ": ")
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if ans = strings.TrimSpace(ans); ans == "" {
+				break
+			}
+			if err := f.Value.Set(ans); err != nil {
+				fmt.Fprintf(c.App.Err, "invalid value for --%s: %v\n", f.Name, err)
+				continue
+			}
+			metas[f].source = SourcePrompt
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInteractiveRequired(t *testing.T) {
+	app, out, errOut := newTestApp(t, FluxInteractive(true))
+	app.In = strings.NewReader("ap\nmany\n3\n")
+	var got string
+	var effective strings.Builder
+	mustCommand(t, app, "deploy", func(c *Context) error {
+		got = fmt.Sprintf("%s/%d from %s", c.GetString("region"), c.GetInt("replicas"), c.Source("replicas"))
+		return c.PrintEffectiveFlags(&effective)
+	}, Flags(String("region").Required().Help("target region"), Int("replicas").Required()))
+
+	if err := app.Parse([]string{"deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "ap/3 from prompt"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(effective.String(), "region=ap") || !strings.Contains(effective.String(), "(prompt)") {
+		t.Errorf("PrintEffectiveFlags:\n%s", effective.String())
+	}
+	if !strings.Contains(out.String(), "region (target region): ") {
+		t.Errorf("prompt not shown, output %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "invalid value for --replicas") {
+		t.Errorf("invalid answer not reported, errors %q", errOut.String())
+	}
+}
+
+func TestInteractiveFallsBack(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		name string
+		set  func(*App)
+	}{
+		{"not a terminal", func(a *App) { a.In = file }},
+		{"empty answer", func(a *App) { a.In = strings.NewReader("\n") }},
+		{"eof", func(a *App) { a.In = strings.NewReader("") }},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t, FluxInteractive(true))
+		tt.set(app)
+		mustCommand(t, app, "deploy", func(*Context) error { return nil }, Flags(String("region").Required()))
+
+		var rerr *ErrRequiredFlag
+		if err := app.Parse([]string{"deploy"}); !errors.As(err, &rerr) {
+			t.Errorf("%s: error = %v, want ErrRequiredFlag", tt.name, err)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+		err  error
+	}{
+		{"y\n", true, nil},
+		{"YES\n", true, nil},
+		{"n\n", false, nil},
+		{"No\n", false, nil},
+		{"\n", false, nil},
+		{"maybe\nyes\n", true, nil},
+		{"", false, io.EOF},
+	}
+	for _, tt := range tests {
+		app, out, _ := newTestApp(t)
+		app.In = strings.NewReader(tt.in)
+		c := &Context{App: app}
+
+		got, err := c.Confirm("delete?")
+		if got != tt.want || err != tt.err {
+			t.Errorf("Confirm with %q = %v, %v, want %v, %v", tt.in, got, err, tt.want, tt.err)
+		}
+		if !strings.HasPrefix(out.String(), "delete? [y/N] ") {
+			t.Errorf("Confirm with %q printed %q", tt.in, out)
+		}
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	app, out, _ := newTestApp(t)
+	app.In = strings.NewReader("John Doe\r\nlast")
+	c := &Context{App: app}
+
+	for _, want := range []string{"John Doe", "last"} {
+		if got, err := c.Prompt("name: "); got != want || err != nil {
+			t.Errorf("Prompt = %q, %v, want %q", got, err, want)
+		}
+	}
+	if _, err := c.Prompt("name: "); err != io.EOF {
+		t.Errorf("Prompt at EOF error = %v, want io.EOF", err)
+	}
+	if want := "name: name: name: "; out.String() != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+}
+
+func TestPromptPasswordNotTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		fmt.Fprint(w, "s3cret\n")
+		w.Close()
+	}()
+
+	for _, in := range []io.Reader{strings.NewReader("s3cret\n"), r} {
+		app, out, _ := newTestApp(t)
+		app.In = in
+		c := &Context{App: app}
+
+		if got, err := c.PromptPassword("password: "); got != "s3cret" || err != nil {
+			t.Errorf("PromptPassword from %T = %q, %v", in, got, err)
+		}
+		if out.String() != "password: " {
+			t.Errorf("PromptPassword from %T printed %q", in, out)
+		}
+	}
+}
+
+func TestPromptInterrupted(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	app, _, _ := newTestApp(t)
+	app.In = pr
+
+	var cleaned bool
+	mustCommand(t, app, "delete", func(c *Context) error {
+		go pr.Close()
+		_, err := c.Confirm("delete everything?")
+		return err
+	}, After(func(*Context) error { cleaned = true; return nil }))
+
+	if err := app.Parse([]string{"delete"}); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("error = %v, want ErrInterrupted", err)
+	}
+	if !cleaned {
+		t.Error("After did not run")
+	}
+}
+
+func TestPromptCanceled(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	app, _, _ := newTestApp(t)
+	app.In = pr
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Context{App: app, Ctx: ctx}
+
+	if _, err := c.Prompt("name: "); !errors.Is(err, ErrInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Prompt error = %v, want ErrInterrupted and context.Canceled", err)
+	}
+
+	// the read left running hands its line to the next prompt
+	go fmt.Fprint(pw, "late\n")
+	c.Ctx = context.Background()
+	if got, err := c.Prompt("name: "); got != "late" || err != nil {
+		t.Errorf("next Prompt = %q, %v, want %q", got, err, "late")
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RunREPL turns the app into an interactive shell: each line read from
+// App.In is split into arguments, quotes respected, and run as if it
+// were a separate invocation. Errors go to OnError and the session
+// continues; Ctrl-C discards the current line. It returns nil at EOF
+// or on "exit", after shutting the plugins down, see App.Shutdown.
+func (a *App) RunREPL() error {
+	return a.finish(a.repl())
+}
+
+// repl is the loop of RunREPL.
+func (a *App) repl() error {
+	for {
+		fmt.Fprint(a.runOut(), a.replPrompt())
+
+		line, err := a.readLineCtx(context.Background())
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(a.runOut())
+			return nil
+		}
+		if errors.Is(err, ErrInterrupted) {
+			fmt.Fprintln(a.runOut()) // Ctrl-C drops the line, like a shell
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := Tokenize(line)
+		if err == nil && len(args) == 0 {
+			continue
+		}
+		if err == nil && len(args) == 1 && args[0] == "exit" {
+			return nil
+		}
+		if err == nil {
+			err = a.Parse(args)
+		}
+		if err != nil {
+			if err2 := a.OnError(&Context{App: a}, err); err2 != nil {
+				a.debug("OnError returned", "error", err2)
+			}
+		}
+	}
+}
+
+// replPrompt returns the prompt set with FluxREPLPrompt, or "name> ".
+func (a *App) replPrompt() string {
+	if a.config.replPrompt != "" {
+		return a.config.replPrompt
+	}
+	return a.Name + "> "
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"time"
+)
+
+// retryPolicy configures how a failing Action is re-run, see Retry.
+type retryPolicy struct {
+	attempts    int
+	backoff     time.Duration
+	exponential bool
+	retryIf     func(error) bool
+}
+
+// retryPolicy returns the command's retry policy, creating it on first use.
+func (c *Command) retryPolicy() *retryPolicy {
+	if c.retry == nil {
+		c.retry = &retryPolicy{attempts: 1}
+	}
+	return c.retry
+}
+
+// shouldRetry reports whether err is worth another attempt: it, or an
+// error it wraps, has a Retryable() bool method returning true, or the
+// RetryIf predicate accepts it. Usage errors are never retried.
+func (p *retryPolicy) shouldRetry(err error) bool {
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		return false
+	}
+
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) && r.Retryable() {
+		return true
+	}
+	return p.retryIf != nil && p.retryIf(err)
+}
+
+// runWithRetry runs c.Action until it succeeds, returns an error that
+// is not retryable, or runs out of attempts. Waiting between attempts
+// stops early when ctx.Ctx is cancelled.
+func (a *App) runWithRetry(ctx *Context, c *Command) error {
+	p := c.retry
+	wait := p.backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = a.runAction(ctx, c); err == nil || attempt >= p.attempts || !p.shouldRetry(err) {
+			return err
+		}
+
+		a.debug("retrying command", "command", c.path, "attempt", attempt, "error", err)
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		if p.exponential {
+			wait *= 2
+		}
+	}
+}
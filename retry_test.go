@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// flaky is a retryable error.
+type flaky struct{}
+
+func (flaky) Error() string   { return "flaky" }
+func (flaky) Retryable() bool { return true }
+
+func TestRetry(t *testing.T) {
+	permanent := errors.New("permanent")
+	tests := []struct {
+		name     string
+		fails    []error // errors of the first attempts, nil after
+		opts     []CommandOption
+		wantErr  error
+		attempts int
+	}{
+		{"success on second try", []error{flaky{}}, nil, nil, 2},
+		{"exhausted", []error{flaky{}, flaky{}, flaky{}, flaky{}}, nil, flaky{}, 3},
+		{"not retryable", []error{permanent}, nil, permanent, 1},
+		{"RetryIf", []error{permanent}, []CommandOption{RetryIf(func(err error) bool { return err == permanent })}, nil, 2},
+		{"usage error", []error{&UsageError{Err: flaky{}}}, nil, flaky{}, 1},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		attempts, afters := 0, 0
+		opts := append([]CommandOption{
+			Retry(3, time.Millisecond), RetryExponential(),
+			After(func(*Context) error { afters++; return nil }),
+		}, tt.opts...)
+		mustCommand(t, app, "fetch", func(*Context) error {
+			attempts++
+			if attempts <= len(tt.fails) {
+				return tt.fails[attempts-1]
+			}
+			return nil
+		}, opts...)
+
+		err := app.Parse([]string{"fetch"})
+		if !errors.Is(err, tt.wantErr) || tt.wantErr == nil && err != nil {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if attempts != tt.attempts || afters != 1 {
+			t.Errorf("%s: %d attempts and %d After runs, want %d and 1", tt.name, attempts, afters, tt.attempts)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// Source is where a flag's value can come from.
+type Source int
+
+const (
+	SourceDefault Source = iota // the flag's default
+	SourceCLI                   // the command line
+	SourceEnv                   // an environment variable, see Env
+	SourceConfig                // FluxConfigValues
+	SourcePrompt                // answered at a prompt, see FluxInteractive
+)
+
+// defaultSources is the precedence used unless App.FlagSources is set:
+// the command line beats the environment beats config beats defaults.
+var defaultSources = []Source{SourceCLI, SourceEnv, SourceConfig, SourceDefault}
+
+func (s Source) String() string {
+	switch s {
+	case SourceCLI:
+		return "cli"
+	case SourceEnv:
+		return "env"
+	case SourceConfig:
+		return "config"
+	case SourceDefault:
+		return "default"
+	case SourcePrompt:
+		return "prompt"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// FlagSources sets the order in which value sources are consulted for
+// every typed flag; the first one holding a value wins. Sources left
+// out are ignored, and a flag with no value from any listed source
+// keeps its default. SourcePrompt has no place in the order: prompts
+// only ask for required flags still missing after every source.
+//
+//	app.FlagSources(cli.SourceEnv, cli.SourceCLI, cli.SourceDefault)
+func (a *App) FlagSources(order ...Source) *App {
+	a.config.sources = order
+	return a
+}
+
+// resolveSources applies env and config values to the flags in ff after
+// fs has parsed the command line, following the App.FlagSources order.
+// Every value that fails to parse is reported, not just the first.
+func (a *App) resolveSources(fs *flag.FlagSet, ff []Flag) error {
+	order := a.config.sources
+	if order == nil {
+		order = defaultSources
+	}
+
+	var errs []error
+	for _, f := range ff {
+		mf, ok := f.(interface{ meta() *flagMeta })
+		if !ok {
+			continue
+		}
+		m := mf.meta()
+		fl := fs.Lookup(m.name)
+		if fl == nil {
+			continue
+		}
+
+		src, val, from := a.pickSource(m, order)
+		switch {
+		case src == SourceCLI:
+			continue
+		case src == SourceDefault:
+			if m.source == SourceDefault {
+				continue
+			}
+			if fi, ok := f.(FlagInfo); ok {
+				val = fi.GetDefaultValue()
+			}
+		}
+
+		// slice, header and count flags add to what the command line
+		// gave; the winning source replaces it instead
+		m.source = SourceDefault
+		if err := fl.Value.Set(val); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for flag --%s from %s: %v", val, m.name, from, err))
+			continue
+		}
+		m.source = src
+		a.trace("resolved flag", "flag", m.name, "source", src, "value", val)
+	}
+	return errors.Join(errs...)
+}
+
+// pickSource returns the first source in order that has a value for m,
+// with the value and a description of where it was found.
+func (a *App) pickSource(m *flagMeta, order []Source) (Source, string, string) {
+	for _, src := range order {
+		switch src {
+		case SourceCLI:
+			if m.source == SourceCLI {
+				return SourceCLI, "", "command line"
+			}
+		case SourceEnv:
+			for _, name := range m.env {
+				if v, ok := os.LookupEnv(name); ok {
+					return SourceEnv, v, "$" + name
+				}
+			}
+		case SourceConfig:
+			if v, ok := a.config.values[m.name]; ok {
+				return SourceConfig, v, "config"
+			}
+		case SourceDefault:
+			return SourceDefault, "", "default"
+		}
+	}
+	return SourceDefault, "", "default"
+}
+
+// flagSource reports where the value of the named flag in fs came from.
+// Plain stdlib flags are either SourceCLI or SourceDefault.
+func flagSource(fs *flag.FlagSet, name string) Source {
+	f := fs.Lookup(name)
+	if f == nil {
+		return SourceDefault
+	}
+	if mf, ok := f.Value.(interface{ meta() *flagMeta }); ok {
+		return mf.meta().source
+	}
+	if isFlagPassed(fs, name) {
+		return SourceCLI
+	}
+	return SourceDefault
+}
+
+// DefaultFunc computes a flag's default from the values of other flags,
+// e.g. a --data-dir under --home:
+//
+//	cli.String("data-dir").DefaultFunc(func(c *cli.Context) string {
+//		return filepath.Join(c.GetString("home"), "data")
+//	})
+//
+// It runs after the command line, environment and config values are
+// applied and before validation, only when the flag got no value from
+// any of them. Computed defaults are resolved in declaration order, so
+// a DefaultFunc can read flags declared before it, their own computed
+// defaults included. An empty result keeps the static default.
+type DefaultFunc func(*Context) string
+
+// resolveDefaults applies the computed defaults of the flags in ff.
+// The flags keep SourceDefault: they still count as not given.
+func resolveDefaults(ctx *Context, ff []Flag) error {
+	var errs []error
+	for _, f := range ff {
+		mf, ok := f.(interface{ meta() *flagMeta })
+		if !ok {
+			continue
+		}
+		m := mf.meta()
+		fl := ctx.Flags.Lookup(m.name)
+		if m.defFunc == nil || m.source != SourceDefault || fl == nil {
+			continue
+		}
+
+		val := m.defFunc(ctx)
+		if val == "" {
+			continue
+		}
+		if err := fl.Value.Set(val); err != nil {
+			errs = append(errs, fmt.Errorf("invalid computed default %q for flag --%s: %v", val, m.name, err))
+		}
+		m.source = SourceDefault
+	}
+	return errors.Join(errs...)
+}
+
+// Source reports where the value of the named flag came from.
+func (c *Context) Source(name string) Source {
+	if c.Flags == nil {
+		return SourceDefault
+	}
+	return flagSource(c.Flags, name)
+}
+
+// PrintEffectiveFlags writes every flag of the running command, globals
+// included, with its value and where that value came from, one per
+// line in name order:
+//
+//	port=3000     (env)
+//	verbose=true  (cli)
+func (c *Context) PrintEffectiveFlags(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range c.FlagNames() {
+		fmt.Fprintf(tw, "%s=%s\t(%s)\n", name, c.Flags.Lookup(name).Value, c.Source(name))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlagSourcesReplaceAccumulated(t *testing.T) {
+	t.Setenv("APP_LEVELS", "warn")
+	t.Setenv("APP_VERBOSE", "3")
+
+	tests := []struct {
+		name    string
+		order   []Source
+		args    []string
+		levels  []string
+		verbose int
+		source  Source
+	}{
+		{"defaults", nil, []string{"run"}, []string{"warn"}, 3, SourceEnv},
+		{"cli beats env", nil, []string{"run", "--level", "debug", "--level", "info", "-vv"}, []string{"debug", "info"}, 2, SourceCLI},
+		{"env beats cli", []Source{SourceEnv, SourceCLI}, []string{"run", "--level", "debug", "-vv"}, []string{"warn"}, 3, SourceEnv},
+		{"default beats cli", []Source{SourceDefault, SourceCLI}, []string{"run", "--level", "debug", "-vv"}, []string{"error"}, 1, SourceDefault},
+	}
+	for _, tt := range tests {
+		app, _, _ := newTestApp(t)
+		app.FlagSources(tt.order...)
+		var levels []string
+		var verbose int
+		var source Source
+		mustCommand(t, app, "run", func(c *Context) error {
+			levels, verbose, source = c.GetStringSlice("level"), c.GetInt("verbose"), c.Source("level")
+			return nil
+		}, Flags(
+			EnumSlice("level", []string{"debug", "info", "warn", "error"}).Default("error").Env("APP_LEVELS"),
+			Count("verbose", "v").Default(1).Env("APP_VERBOSE"),
+		))
+
+		if err := app.Parse(tt.args); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(levels, tt.levels) || verbose != tt.verbose || source != tt.source {
+			t.Errorf("%s: got %q, %d from %s, want %q, %d from %s",
+				tt.name, levels, verbose, source, tt.levels, tt.verbose, tt.source)
+		}
+	}
+}
+
+func TestFlagSourcesHeaderDefault(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.FlagSources(SourceDefault, SourceCLI)
+	var got [][2]string
+	mustCommand(t, app, "run", func(c *Context) error {
+		got = c.GetPairs("header")
+		return nil
+	}, Flags(HeaderFlag("header").Default([2]string{"Accept", "json"})))
+
+	if err := app.Parse([]string{"run", "--header", "X-Id:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]string{{"Accept", "json"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFlagSourcesPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []Source
+		cli   bool
+		env   bool
+		want  string
+		src   Source
+	}{
+		{"cli", nil, true, true, "cli", SourceCLI},
+		{"env", nil, false, true, "env", SourceEnv},
+		{"config", nil, false, false, "config", SourceConfig},
+		{"reordered", []Source{SourceConfig, SourceEnv, SourceCLI}, true, true, "config", SourceConfig},
+		{"config left out", []Source{SourceCLI, SourceDefault}, false, true, "default", SourceDefault},
+	}
+	for _, tt := range tests {
+		if tt.env {
+			t.Setenv("APP_PORT", "env")
+		} else {
+			t.Setenv("APP_PORT", "") // restored after the test
+			os.Unsetenv("APP_PORT")
+		}
+		app, _, _ := newTestApp(t, FluxConfigValues(map[string]string{"port": "config"}))
+		app.FlagSources(tt.order...)
+		var got string
+		var src Source
+		mustCommand(t, app, "serve", func(c *Context) error {
+			got, src = c.GetString("port"), c.Source("port")
+			return nil
+		}, Flags(String("port").Default("default").Env("APP_PORT")))
+
+		args := []string{"serve"}
+		if tt.cli {
+			args = append(args, "--port", "cli")
+		}
+		if err := app.Parse(args); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want || src != tt.src {
+			t.Errorf("%s: port = %q from %s, want %q from %s", tt.name, got, src, tt.want, tt.src)
+		}
+	}
+}
+
+func TestDefaultFuncChain(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	var got string
+	mustCommand(t, app, "serve", func(c *Context) error {
+		got = c.GetString("data-dir") + " " + c.GetString("cache-dir") + " " + c.Source("cache-dir").String()
+		return nil
+	}, Flags(
+		String("home").Default("/srv"),
+		String("data-dir").DefaultFunc(func(c *Context) string {
+			return filepath.Join(c.GetString("home"), "data")
+		}),
+		String("cache-dir").Default("/tmp").DefaultFunc(func(c *Context) string {
+			return filepath.Join(c.GetString("data-dir"), "cache")
+		}),
+	))
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "/srv/data /srv/data/cache default"},
+		{[]string{"--home", "/opt"}, "/opt/data /opt/data/cache default"},
+		{[]string{"--data-dir", "/d"}, "/d /d/cache default"},
+		{[]string{"--cache-dir", "/c"}, "/srv/data /c cli"},
+	}
+	for _, tt := range tests {
+		if err := app.Parse(append([]string{"serve"}, tt.args...)); err != nil || got != tt.want {
+			t.Errorf("%q = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestPrintEffectiveFlags(t *testing.T) {
+	t.Setenv("APP_PORT", "8080")
+	app, _, _ := newTestApp(t, FluxConfigValues(map[string]string{"host": "example.com", "port": "9000"}))
+	app.Flags(Bool("verbose", "v"))
+	var b strings.Builder
+	mustCommand(t, app, "serve", func(c *Context) error {
+		return c.PrintEffectiveFlags(&b)
+	}, Flags(
+		String("host"),
+		Int("port").Env("APP_PORT"),
+		Duration("timeout").Default(time.Second),
+	))
+
+	if err := app.Parse([]string{"serve", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"help=false        (default)\n" +
+		"host=example.com  (config)\n" +
+		"port=8080         (env)\n" +
+		"timeout=1s        (default)\n" +
+		"verbose=true      (cli)\n"
+	if b.String() != want {
+		t.Errorf("PrintEffectiveFlags =\n%s\nwant\n%s", b.String(), want)
+	}
+}
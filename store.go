@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is a key/value store for sharing data between plugins, hooks
+// and commands. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(key string) (any, bool)
+	Set(key string, v any)
+	Delete(key string)
+
+	// Keys returns the keys set in this store, sorted.
+	Keys() []string
+
+	// Namespace returns a view of the store whose keys are kept apart
+	// from the parent's and other namespaces', see MapStore.
+	Namespace(name string) Store
+}
+
+// MapStore is the in-memory Store. Namespaces share the parent's map:
+// a key k set in namespace "a", itself in namespace "b", is stored as
+// "b/a/k".
+type MapStore struct {
+	shared *mapData
+	prefix string
+}
+
+type mapData struct {
+	mu sync.RWMutex
+	m  map[string]any
+}
+
+// NewMapStore returns an empty MapStore.
+func NewMapStore() *MapStore {
+	return &MapStore{shared: &mapData{m: make(map[string]any)}}
+}
+
+func (s *MapStore) Get(key string) (any, bool) {
+	s.shared.mu.RLock()
+	defer s.shared.mu.RUnlock()
+	v, ok := s.shared.m[s.prefix+key]
+	return v, ok
+}
+
+func (s *MapStore) Set(key string, v any) {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+	s.shared.m[s.prefix+key] = v
+}
+
+func (s *MapStore) Delete(key string) {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+	delete(s.shared.m, s.prefix+key)
+}
+
+// Keys returns the keys of this namespace, without its prefix. Keys of
+// nested namespaces are included with their own prefix.
+func (s *MapStore) Keys() []string {
+	s.shared.mu.RLock()
+	defer s.shared.mu.RUnlock()
+
+	var keys []string
+	for k := range s.shared.m {
+		if rest, ok := strings.CutPrefix(k, s.prefix); ok {
+			keys = append(keys, rest)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *MapStore) Namespace(name string) Store {
+	return &MapStore{shared: s.shared, prefix: s.prefix + name + "/"}
+}
+
+// Store returns the app-wide store, a MapStore created by New. Plugins
+// use it to publish things commands need, e.g. a database handle set
+// in Sparkle and read with c.App.Store().Get("db"). Per-command data
+// belongs in Context.Store.
+func (a *App) Store() Store {
+	return a.store
+}
+
+// Store returns the store of the running command: a namespace of
+// App.Store named "cmd:" followed by the command path, e.g.
+// "cmd:server start". Plugins and hooks can keep per-command data
+// there without clashing with other commands.
+func (c *Context) Store() Store {
+	return c.App.store.Namespace("cmd:" + c.Path())
+}
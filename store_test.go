@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestContextStoreIsolation(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	got := make(map[string]any)
+	count := func(c *Context) error {
+		n, _ := c.Store().Get("runs")
+		runs, _ := n.(int)
+		c.Store().Set("runs", runs+1)
+		got[c.Path()] = runs + 1
+		return nil
+	}
+	mustCommand(t, app, "server", count)
+	mustCommand(t, app, "server start", count)
+
+	for _, args := range [][]string{{"server"}, {"server", "start"}, {"server", "start"}} {
+		if err := app.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got["server"] != 1 || got["server start"] != 2 {
+		t.Errorf("runs = %v, want server 1 and server start 2", got)
+	}
+
+	want := []string{"cmd:server start/runs", "cmd:server/runs"}
+	if keys := app.Store().Keys(); !slices.Equal(keys, want) {
+		t.Errorf("App.Store keys = %q, want %q", keys, want)
+	}
+	if _, ok := app.Store().Get("runs"); ok {
+		t.Error("a command's key leaked into App.Store")
+	}
+}
+
+// dbPlugin publishes a handle in App.Store for commands to use.
+type dbPlugin struct{ handle string }
+
+func (p dbPlugin) Sparkle(a *App) error {
+	a.Store().Set("db", p.handle)
+	return nil
+}
+
+func TestAppStoreFromPlugin(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Adopt(dbPlugin{handle: "postgres://local"})
+
+	var got any
+	mustCommand(t, app, "migrate", func(c *Context) error {
+		got, _ = c.App.Store().Get("db")
+		return nil
+	})
+	if err := app.Parse([]string{"migrate"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "postgres://local" {
+		t.Errorf("db = %v, want the plugin's handle", got)
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structField is a struct field declared as a flag through a `cli` tag:
+//
+//	Port int `cli:"port,default=8080,help=server port,short=p"`
+//
+// Options after the name are key=value pairs; a comma not followed by a
+// known key stays part of the previous value, so help text may contain
+// commas. A tag of "-" skips the field.
+type structField struct {
+	name   string
+	def    string
+	help   string
+	short  string
+	hasDef bool
+	value  reflect.Value
+}
+
+// structFields returns the tagged fields of the struct v points to.
+// Unexported and untagged fields are skipped.
+func structFields(v any) ([]structField, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected pointer to struct, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var out []structField
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("cli")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		f := structField{value: rv.Field(i)}
+		parts := strings.Split(tag, ",")
+		f.name = parts[0]
+		if f.name == "" {
+			return nil, fmt.Errorf("field %s: missing flag name", sf.Name)
+		}
+
+		var last *string
+		for _, p := range parts[1:] {
+			key, val, ok := strings.Cut(p, "=")
+			switch {
+			case ok && key == "default":
+				f.def, f.hasDef = val, true
+				last = &f.def
+			case ok && key == "help":
+				f.help = val
+				last = &f.help
+			case ok && key == "short":
+				f.short = val
+				last = &f.short
+			case last != nil:
+				*last += "," + p
+			default:
+				return nil, fmt.Errorf("field %s: unknown tag option %q", sf.Name, p)
+			}
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// FlagsFromStruct declares one flag per `cli` tagged field of the struct
+// v points to. The flags write parsed values straight into the fields.
+// A field's current value is its default unless the tag sets one.
+//
+// Supported field types are string, bool, int, float64 and
+// time.Duration.
+func FlagsFromStruct(v any) ([]Flag, error) {
+	fields, err := structFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Flag
+	for _, sf := range fields {
+		f, err := sf.flag()
+		if err != nil {
+			return nil, fmt.Errorf("flag --%s: %w", sf.name, err)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// BindStruct registers the flags declared by v on cmd, see
+// FlagsFromStruct. Parsed values are written into v before the
+// command's Before hook runs.
+func BindStruct(cmd *Command, v any) error {
+	ff, err := FlagsFromStruct(v)
+	if err != nil {
+		return err
+	}
+	if err := checkFlagNames(append(cmd.flags[:len(cmd.flags):len(cmd.flags)], ff...)); err != nil {
+		return fmt.Errorf("command %q: %w", cmd.path, err)
+	}
+	Flags(ff...)(cmd)
+	return nil
+}
+
+// flag builds the flag for sf, bound to the field.
+func (sf structField) flag() (Flag, error) {
+	var short []string
+	if sf.short != "" {
+		short = []string{sf.short}
+	}
+
+	switch ptr := sf.value.Addr().Interface().(type) {
+	case *string:
+		f := String(sf.name, short...).Help(sf.help)
+		f.dest = ptr
+		f.Default(sf.defOr(*ptr))
+		return f, nil
+	case *bool:
+		f := Bool(sf.name, short...).Help(sf.help)
+		f.dest = ptr
+		v, err := strconv.ParseBool(sf.defOr(strconv.FormatBool(*ptr)))
+		if err != nil {
+			return nil, fmt.Errorf("bad default %q", sf.def)
+		}
+		f.Default(v)
+		return f, nil
+	case *int:
+		f := Int(sf.name, short...).Help(sf.help)
+		f.dest = ptr
+		v, err := strconv.Atoi(sf.defOr(strconv.Itoa(*ptr)))
+		if err != nil {
+			return nil, fmt.Errorf("bad default %q", sf.def)
+		}
+		f.Default(v)
+		return f, nil
+	case *float64:
+		f := Float64(sf.name, short...).Help(sf.help)
+		f.dest = ptr
+		v, err := strconv.ParseFloat(sf.defOr(strconv.FormatFloat(*ptr, 'g', -1, 64)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad default %q", sf.def)
+		}
+		f.Default(v)
+		return f, nil
+	case *time.Duration:
+		f := Duration(sf.name, short...).Help(sf.help)
+		f.dest = ptr
+		v, err := time.ParseDuration(sf.defOr(ptr.String()))
+		if err != nil {
+			return nil, fmt.Errorf("bad default %q", sf.def)
+		}
+		f.Default(v)
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", sf.value.Type())
+}
+
+// defOr returns the tag default if one was given, else cur.
+func (sf structField) defOr(cur string) string {
+	if sf.hasDef {
+		return sf.def
+	}
+	return cur
+}
+
+// Unmarshal copies parsed flag values into the `cli` tagged fields of
+// the struct v points to. Fields with no matching flag are left alone;
+// a value that does not convert to the field's type is an error.
+func (c *Context) Unmarshal(v any) error {
+	fields, err := structFields(v)
+	if err != nil {
+		return err
+	}
+	if c.Flags == nil {
+		return nil
+	}
+
+	for _, sf := range fields {
+		f := c.Flags.Lookup(sf.name)
+		if f == nil {
+			continue
+		}
+		if err := setField(sf.value, f.Value); err != nil {
+			return fmt.Errorf("flag --%s: %w", sf.name, err)
+		}
+	}
+	return nil
+}
+
+// setField stores fv into dst, converting from its string form when
+// the flag does not already hold a value of dst's type.
+func setField(dst reflect.Value, fv flag.Value) error {
+	if g, ok := fv.(flag.Getter); ok {
+		if got := reflect.ValueOf(g.Get()); got.IsValid() && got.Type().AssignableTo(dst.Type()) {
+			dst.Set(got)
+			return nil
+		}
+	}
+
+	s := fv.String()
+	fail := fmt.Errorf("cannot unmarshal %q into %s", s, dst.Type())
+	if dst.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fail
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fail
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return fail
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return fail
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return fail
+		}
+		dst.SetFloat(n)
+	default:
+		return fail
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// suggestFlag adds a "did you mean" hint to an unknown flag error from
+// fs.Parse, naming the closest flag of fs. Other errors, and unknown
+// flags with nothing close, are returned unchanged.
+func suggestFlag(fs *flag.FlagSet, err error) error {
+	const prefix = "flag provided but not defined: -"
+	msg := err.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return err
+	}
+	name := strings.TrimPrefix(msg, prefix)
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	best := closest(name, names)
+	if best == "" {
+		return err
+	}
+
+	dashes := "--"
+	if len(best) == 1 {
+		dashes = "-"
+	}
+	return fmt.Errorf("%w, did you mean %s%s?", err, dashes, best)
+}
+
+// closest returns the candidate nearest to s by edit distance, or ""
+// if none is within a third of the length of s (at least 1 edit).
+// Words shorter than 3 letters are too short to guess from.
+func closest(s string, candidates []string) string {
+	if len(s) < 3 {
+		return ""
+	}
+	limit := max(len(s)/3, 1)
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if d := levenshtein(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b, counting a
+// swap of two adjacent characters as one edit.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// three rows: two back, previous, current
+	pp := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], pp[j-2]+1)
+			}
+		}
+		pp, prev, cur = prev, cur, pp
+	}
+	return prev[len(rb)]
+}
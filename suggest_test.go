@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestFlag(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	app.Flags(Bool("verbose"))
+	mustCommand(t, app, "build", func(*Context) error { return nil }, Flags(String("output", "o")))
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"--verbsoe", "flag provided but not defined: -verbsoe, did you mean --verbose?"},
+		{"--otput", "flag provided but not defined: -otput, did you mean --output?"},
+		{"--hepl", "flag provided but not defined: -hepl, did you mean --help?"},
+		{"--frobnicate", "flag provided but not defined: -frobnicate"},
+		{"-x", "flag provided but not defined: -x"},
+	}
+	for _, tt := range tests {
+		err := app.Parse([]string{"build", tt.flag})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.flag, err, tt.want)
+			continue
+		}
+		if strings.Contains(tt.want, "?") != strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("%s: error = %v", tt.flag, err)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"verbose", "verbose", 0},
+		{"verbsoe", "verbose", 1},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Table collects rows and writes them as aligned columns, see
+// Context.Table.
+//
+//	t := c.Table([]string{"NAME", "SIZE"})
+//	t.AddRow("a.txt", "12")
+//	t.AddRow("b.txt", "3400")
+//	return t.Render()
+type Table struct {
+	w       io.Writer
+	headers []string
+	rows    [][]string
+	border  bool
+}
+
+// Table starts a table written to Out by Render.
+func (c *Context) Table(headers []string) *Table {
+	return &Table{w: c.Out(), headers: headers}
+}
+
+// AddRow appends a row. Missing cells are left blank.
+func (t *Table) AddRow(cells ...string) *Table {
+	t.rows = append(t.rows, cells)
+	return t
+}
+
+// Border draws lines around and between the columns.
+func (t *Table) Border(on bool) *Table {
+	t.border = on
+	return t
+}
+
+// Render writes the table to Out. Columns are as wide as their
+// widest cell, counting wide East Asian characters as two columns;
+// when Out is a terminal too narrow for that, the widest columns are
+// cut down and their cells end with an ellipsis.
+// Headers are bold on terminals unless NO_COLOR is set.
+func (t *Table) Render() error {
+	w := t.w
+
+	cols := len(t.headers)
+	for _, r := range t.rows {
+		cols = max(cols, len(r))
+	}
+	if cols == 0 {
+		return nil
+	}
+
+	widths := make([]int, cols)
+	for _, r := range append([][]string{t.headers}, t.rows...) {
+		for i, cell := range r {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	if f, ok := outFile(w); ok {
+		if n, ok := termWidth(f.Fd()); ok {
+			t.fit(widths, n)
+		}
+	}
+
+	var out strings.Builder
+	sep := func() {
+		if !t.border {
+			return
+		}
+		for _, wd := range widths {
+			out.WriteString("+" + strings.Repeat("-", wd+2))
+		}
+		out.WriteString("+\n")
+	}
+	line := func(r []string, bold bool) {
+		var b strings.Builder
+		for i, wd := range widths {
+			var cell string
+			if i < len(r) {
+				cell = clip(r[i], wd)
+			}
+			cell += strings.Repeat(" ", wd-displayWidth(cell))
+			if bold {
+				cell = "\x1b[1m" + cell + "\x1b[0m"
+			}
+
+			switch {
+			case t.border:
+				b.WriteString("| " + cell + " ")
+			case i > 0:
+				b.WriteString("  " + cell)
+			default:
+				b.WriteString(cell)
+			}
+		}
+		if t.border {
+			b.WriteString("|")
+		}
+		// no trailing blanks after the last column
+		out.WriteString(strings.TrimRight(b.String(), " ") + "\n")
+	}
+
+	sep()
+	if len(t.headers) > 0 {
+		line(t.headers, colorable(w))
+		sep()
+	}
+	for _, r := range t.rows {
+		line(r, false)
+	}
+	if len(t.rows) > 0 {
+		sep()
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// fit narrows widths until a line fits in n columns, always taking
+// from the widest column and keeping at least 3 per column.
+func (t *Table) fit(widths []int, n int) {
+	total := func() int {
+		sum := 0
+		for _, wd := range widths {
+			sum += wd
+		}
+		if t.border {
+			return sum + 3*len(widths) + 1
+		}
+		return sum + 2*(len(widths)-1)
+	}
+
+	for total() > n {
+		widest := 0
+		for i, wd := range widths {
+			if wd > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 3 {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// clip cuts s to at most n columns, ending a cut with an ellipsis.
+func clip(s string, n int) string {
+	if displayWidth(s) <= n {
+		return s
+	}
+	return cutWidth(s, n-1) + "…"
+}
+
+// cutWidth returns the longest prefix of s at most n columns wide.
+func cutWidth(s string, n int) string {
+	w := 0
+	for i, r := range s {
+		if w += runeWidth(r); w > n {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// displayWidth returns the number of terminal columns s takes.
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth is 0 for combining marks and invisible format characters
+// such as zero-width joiners, 2 for East Asian wide and fullwidth
+// characters, 1 for everything else.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// colorable reports whether styling escapes may be written to w: it
+// is a terminal and NO_COLOR is not set.
+func colorable(w io.Writer) bool {
+	return ttyOut(w) && os.Getenv("NO_COLOR") == ""
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTableRender(t *testing.T) {
+	var b strings.Builder
+	tb := &Table{w: &b, headers: []string{"NAME", "SIZE"}}
+	tb.AddRow("a.txt", "12").AddRow("日本.txt", "3400").AddRow("héllo")
+	if err := tb.Render(); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"NAME      SIZE\n" +
+		"a.txt     12\n" +
+		"日本.txt  3400\n" +
+		"héllo\n"
+	if b.String() != want {
+		t.Errorf("Render =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestTableBorder(t *testing.T) {
+	var b strings.Builder
+	tb := &Table{w: &b, headers: []string{"K", "V"}}
+	tb.Border(true).AddRow("ü", "日")
+	if err := tb.Render(); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"+---+----+\n" +
+		"| K | V  |\n" +
+		"+---+----+\n" +
+		"| ü | 日 |\n" +
+		"+---+----+\n"
+	if b.String() != want {
+		t.Errorf("Render =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestTableFit(t *testing.T) {
+	tb := &Table{}
+	widths := []int{4, 30, 10}
+	tb.fit(widths, 30)
+	if want := []int{4, 12, 10}; !slices.Equal(widths, want) {
+		t.Errorf("fit = %v, want %v", widths, want)
+	}
+
+	widths = []int{5, 5}
+	tb.fit(widths, 4)
+	if want := []int{3, 3}; !slices.Equal(widths, want) {
+		t.Errorf("fit below the minimum = %v, want %v", widths, want)
+	}
+}
+
+func TestClip(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 5, "hello"},
+		{"hello!", 5, "hell…"},
+		{"日本語テキスト", 6, "日本…"},
+		{"日本語", 6, "日本語"},
+		{"ab日本", 4, "ab…"},
+	}
+	for _, tt := range tests {
+		got := clip(tt.s, tt.n)
+		if got != tt.want || displayWidth(got) > tt.n {
+			t.Errorf("clip(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
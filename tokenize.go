@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tokenize splits line into arguments like a POSIX shell would, minus
+// expansions: single quotes keep everything literal, double quotes
+// allow \" and \\ escapes, and outside quotes a backslash escapes the
+// next character. An unterminated quote or trailing backslash is an
+// error.
+//
+//	Tokenize(`greet "John Doe" 'it''s'`) // ["greet", "John Doe", "its"]
+func Tokenize(line string) ([]string, error) {
+	return tokenize(line, true)
+}
+
+// tokenize is Tokenize, with backslashes kept literal unless escapes
+// is set.
+func tokenize(line string, escapes bool) ([]string, error) {
+	var (
+		out   []string
+		cur   strings.Builder
+		inTok bool // cur holds a token, possibly empty ("")
+		quote rune // active quote character, 0 if none
+		esc   bool
+	)
+
+	for _, r := range line {
+		switch {
+		case esc:
+			if quote == '"' && r != '"' && r != '\\' {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			esc = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\' && escapes:
+			esc, inTok = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inTok = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inTok {
+				out = append(out, cur.String())
+				cur.Reset()
+				inTok = false
+			}
+		default:
+			cur.WriteRune(r)
+			inTok = true
+		}
+	}
+
+	switch {
+	case esc:
+		return nil, errors.New("unterminated escape at end of line")
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inTok {
+		out = append(out, cur.String())
+	}
+	return out, nil
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  greet \t John\n", []string{"greet", "John"}},
+		{`greet "John Doe"`, []string{"greet", "John Doe"}},
+		{`say "it's" 'a "quote"'`, []string{"say", "it's", `a "quote"`}},
+		{`'it''s'`, []string{"its"}},
+		{`"a \"b\" c"`, []string{`a "b" c`}},
+		{`"back\\slash" "\n"`, []string{`back\slash`, `\n`}},
+		{`'no \" escapes'`, []string{`no \" escapes`}},
+		{`a\ b \'c`, []string{"a b", "'c"}},
+		{`"" ''`, []string{"", ""}},
+		{`x"y z"w`, []string{"xy zw"}},
+	}
+	for _, tt := range tests {
+		got, err := Tokenize(tt.line)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, %v, want %q", tt.line, got, err, tt.want)
+		}
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`greet "John`, `unterminated " quote`},
+		{`greet 'John`, `unterminated ' quote`},
+		{`"it's`, `unterminated " quote`},
+		{`greet \`, "unterminated escape"},
+	}
+	for _, tt := range tests {
+		if _, err := Tokenize(tt.line); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Tokenize(%q) error = %v, want %q", tt.line, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPTY returns both ends of a new pseudo-terminal, skipping the
+// test where none can be had.
+func openPTY(t *testing.T) (master, tty *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var n, unlock uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skip("no pseudo-terminals:", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Skip("no pseudo-terminals:", errno)
+	}
+	tty, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return master, tty
+}
+
+func TestContextJSONTerminal(t *testing.T) {
+	master, tty := openPTY(t)
+	app, _, _ := newTestApp(t)
+	app.Out = tty
+	c := &Context{App: app}
+
+	if err := c.JSON(map[string]any{"name": "web", "ports": []int{80}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the terminal turns "\n" into "\r\n"
+	want := "{\r\n  \"name\": \"web\",\r\n  \"ports\": [\r\n    80\r\n  ]\r\n}\r\n"
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(master, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("JSON on a terminal wrote %q, want %q", got, want)
+	}
+}
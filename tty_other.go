@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package cli
+
+// isTerminal always reports false here; callers fall back to plain I/O.
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func withoutEcho(fd uintptr, fn func() error) error {
+	return fn()
+}
+
+func termWidth(fd uintptr) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validate checks the command tree for mistakes that otherwise only
+// show up when a command is run, and reports all of them at once:
+//
+//   - commands without subcommands that have no Action
+//   - commands with an empty name
+//   - an alias used by two sibling commands, or equal to a sibling's name
+//   - flags of a command, globals included, sharing a name, short
+//     name or alias
+//   - required flags that also have a default
+//
+// It is meant for a test, e.g. TestCLIWellFormed.
+func (a *App) Validate() error {
+	errs := a.missingActions()
+
+	if err := checkFlagNames(a.globals); err != nil {
+		errs = append(errs, fmt.Errorf("global flags: %w", err))
+	}
+	errs = append(errs, requiredDefaults("global flags", a.globals)...)
+
+	var walk func(n *node, prefix string)
+	walk = func(n *node, prefix string) {
+		owner := make(map[string]string) // sibling names and aliases
+		for _, name := range sortedKeys(n.child) {
+			owner[name] = name
+		}
+
+		for _, name := range sortedKeys(n.child) {
+			child := n.child[name]
+			full := strings.TrimSpace(prefix + " " + name)
+			if name == "" {
+				errs = append(errs, fmt.Errorf("command %q has an empty name", prefix))
+			}
+
+			if cmd := child.cmd; cmd != nil {
+				for _, al := range cmd.Aliases {
+					if o, ok := owner[al]; ok && o != name {
+						errs = append(errs, fmt.Errorf("command %q: alias %q already used by %q", full, al, o))
+						continue
+					}
+					owner[al] = name
+				}
+
+				if err := checkFlagNames(cmd.withGlobals(a.inheritedFlags(cmd))); err != nil {
+					errs = append(errs, fmt.Errorf("command %q: %w", full, err))
+				}
+				errs = append(errs, requiredDefaults(fmt.Sprintf("command %q", full), cmd.flags)...)
+			}
+			walk(child, full)
+		}
+	}
+	walk(a.root, "")
+
+	return errors.Join(errs...)
+}
+
+// requiredDefaults reports required flags in ff that have a non-zero
+// default, which makes the requirement meaningless. Zero is what help
+// leaves out, see zeroDefault.
+func requiredDefaults(where string, ff []Flag) []error {
+	var errs []error
+	for _, f := range ff {
+		fi, ok := f.(FlagInfo)
+		if !ok || !fi.IsRequired() {
+			continue
+		}
+		if def := fi.GetDefaultValue(); !zeroDefault(def) {
+			errs = append(errs, fmt.Errorf("%s: required flag --%s has default %q", where, fi.GetName(), def))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	noop := func(*Context) error { return nil }
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", noop, Alias("up"))
+	mustCommand(t, app, "server stop", noop, Alias("up"))
+	mustCommand(t, app, "server watch", nil)
+	mustCommand(t, app, "deploy", noop, Flags(String("target", "t").Required().Default("prod")))
+	mustCommand(t, app, "push", noop, Flags(String("tag", "g")))
+	app.Flags(String("git", "g")) // added after push
+
+	err := app.Validate()
+	if err == nil {
+		t.Fatal("Validate found nothing")
+	}
+	for _, want := range []string{
+		"no action defined for: server watch",
+		`command "server stop": alias "up" already used by "start"`,
+		`command "push": short flag -g already used by --tag`,
+		`command "deploy": required flag --target has default "prod"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate lacks %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateWellFormed(t *testing.T) {
+	app, _, _ := newTestApp(t)
+	mustCommand(t, app, "server", nil)
+	mustCommand(t, app, "server start", func(*Context) error { return nil }, Alias("up"),
+		Flags(String("port", "p").Required(), Duration("timeout").Required(), Int("workers").Required(),
+			Float64("ratio").Required(), Bytes("limit").Required(), Time("since", "").Required()))
+	if err := app.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}